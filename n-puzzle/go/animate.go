@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"algorithms-solutions/n-puzzle/go/npuzzle"
+)
+
+// animation replays a solution on a terminal, redrawing the board after every
+// move with the slid tile in reverse video. It waits delay between moves, or
+// for a keypress when keys is set.
+type animation struct {
+	w     io.Writer
+	delay time.Duration
+	keys  *bufio.Reader
+}
+
+// Keys of the step-through mode.
+const (
+	keyNext = iota
+	keyBack
+	keyQuit
+)
+
+const stepHelp = "space, enter or → next · b or ← back · q quit"
+
+// newAnimation draws on w. With step set, keypresses are read from the
+// terminal, which the returned function restores, also on interrupt.
+func newAnimation(w io.Writer, delay time.Duration, step bool) (*animation, func(), error) {
+	a := &animation{w: w, delay: delay}
+	if !step {
+		return a, func() {}, nil
+	}
+	tty, restore, err := openTerminal()
+	if err != nil {
+		return nil, nil, err
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			restore()
+			os.Exit(130)
+		}
+	}()
+	a.keys = bufio.NewReader(tty)
+	return a, func() {
+		signal.Stop(interrupt)
+		close(interrupt)
+		restore()
+	}, nil
+}
+
+// play shows start and the board after each move of path.
+func (a *animation) play(start npuzzle.Board, path []npuzzle.Move) {
+	board := make(npuzzle.Board, len(start))
+	for i, row := range start {
+		board[i] = append([]int(nil), row...)
+	}
+	a.frame(board, fmt.Sprintf("start, %d moves", len(path)), 0)
+	for k := 0; k < len(path); {
+		key := keyNext
+		if a.keys != nil {
+			key = a.key()
+		} else {
+			time.Sleep(a.delay)
+		}
+		switch key {
+		case keyQuit:
+			return
+		case keyBack:
+			if k == 0 {
+				continue
+			}
+			k--
+			tile, _ := board.Apply(path[k].Reverse())
+			a.frame(board, fmt.Sprintf("move %d/%d: %d slides back %s", k, len(path), tile, path[k]), tile)
+		case keyNext:
+			tile, _ := board.Apply(path[k])
+			k++
+			a.frame(board, fmt.Sprintf("move %d/%d: %d slides %s", k, len(path), tile, path[k-1].Reverse()), tile)
+		}
+	}
+}
+
+// frame clears the screen and draws board under title, highlighting moved.
+func (a *animation) frame(board npuzzle.Board, title string, moved int) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString(title)
+	b.WriteString("\n\n")
+	width := len(strconv.Itoa(len(board)*len(board[0]) - 1))
+	for _, row := range board {
+		for j, tile := range row {
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			switch {
+			case tile == 0:
+				b.WriteString(strings.Repeat(" ", width))
+			case tile == moved:
+				fmt.Fprintf(&b, "\x1b[7m%*d\x1b[0m", width, tile)
+			default:
+				fmt.Fprintf(&b, "%*d", width, tile)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	if a.keys != nil {
+		b.WriteString("\n" + stepHelp + "\n")
+	}
+	io.WriteString(a.w, b.String())
+}
+
+// key waits for a key of the step-through mode; the end of input quits.
+func (a *animation) key() int {
+	for {
+		c, err := a.keys.ReadByte()
+		if err != nil {
+			return keyQuit
+		}
+		switch c {
+		case ' ', '\n', '\r', 'n':
+			return keyNext
+		case 'b', 'p':
+			return keyBack
+		case 'q', 'Q':
+			return keyQuit
+		case 0x1b:
+			// Arrow keys arrive as ESC [ C (right) and ESC [ D (left).
+			if c, _ := a.keys.ReadByte(); c != '[' {
+				continue
+			}
+			switch c, _ := a.keys.ReadByte(); c {
+			case 'C':
+				return keyNext
+			case 'D':
+				return keyBack
+			}
+		}
+	}
+}
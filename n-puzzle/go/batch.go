@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"algorithms-solutions/n-puzzle/go/npuzzle"
+)
+
+// batchRecord is one row of the batch report. Length is -1 for an
+// unsolvable instance.
+type batchRecord struct {
+	Instance   int     `json:"instance"`
+	Line       int     `json:"line"`
+	Rows       int     `json:"rows"`
+	Cols       int     `json:"cols"`
+	Length     int     `json:"length"`
+	Expanded   int64   `json:"expanded"`
+	Generated  int64   `json:"generated"`
+	Iterations int     `json:"iterations"`
+	TableBytes int64   `json:"table_bytes"`
+	ElapsedMs  float64 `json:"elapsed_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+var batchColumns = []string{"instance", "line", "rows", "cols", "length", "expanded", "generated", "iterations", "table_bytes", "elapsed_ms", "error"}
+
+func (rec batchRecord) csv() []string {
+	return []string{
+		strconv.Itoa(rec.Instance),
+		strconv.Itoa(rec.Line),
+		strconv.Itoa(rec.Rows),
+		strconv.Itoa(rec.Cols),
+		strconv.Itoa(rec.Length),
+		strconv.FormatInt(rec.Expanded, 10),
+		strconv.FormatInt(rec.Generated, 10),
+		strconv.Itoa(rec.Iterations),
+		strconv.FormatInt(rec.TableBytes, 10),
+		strconv.FormatFloat(rec.ElapsedMs, 'f', 3, 64),
+		rec.Error,
+	}
+}
+
+func solveInstance(ctx context.Context, opts npuzzle.Options, timeout time.Duration, k int, inst npuzzle.Instance) batchRecord {
+	rec := batchRecord{Instance: k + 1, Line: inst.Line, Rows: len(inst.Start), Cols: len(inst.Start[0]), Length: -1}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	solution, err := npuzzle.Solve(ctx, inst.Start, inst.Goal, opts)
+	switch {
+	case err == nil:
+		rec.Length = len(solution.Moves)
+	case !errors.Is(err, npuzzle.ErrUnsolvable):
+		rec.Error = err.Error()
+	}
+	stats := solution.Stats
+	rec.Expanded = stats.Expanded
+	rec.Generated = stats.Generated
+	rec.Iterations = stats.Iterations
+	rec.TableBytes = stats.Memory
+	rec.ElapsedMs = float64(stats.Elapsed.Nanoseconds()) / 1e6
+	return rec
+}
+
+// batchMain implements "n-puzzle batch": it solves every puzzle of a file
+// (stdin if none is given) on a pool of workers and writes one report row
+// per puzzle, in input order.
+func batchMain(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	var opts npuzzle.Options
+	fs.StringVar(&opts.Heuristic, "heuristic", "manhattan", "heuristic: "+strings.Join(npuzzle.HeuristicNames, ", "))
+	fs.StringVar(&opts.PDB.Partition, "pdb", "", "pattern database partition, e.g. 6-6-3 (default: groups of at most 6 tiles)")
+	fs.StringVar(&opts.PDB.Dir, "pdb-dir", "", "directory caching pattern databases (default: user cache dir)")
+	fs.StringVar(&opts.Strategy, "strategy", "ida", "search strategy: "+strings.Join(npuzzle.StrategyNames, ", "))
+	fs.Float64Var(&opts.Epsilon, "epsilon", 0.5, "weighted A* bound: solutions are at most (1+epsilon) times optimal")
+	workers := fs.Int("workers", runtime.NumCPU(), "puzzles solved at the same time")
+	format := fs.String("format", "csv", "report format: csv or json (one object per line)")
+	goalInput := fs.Bool("goal", false, "blocks hold an explicit goal board after the start board instead of the blank index line")
+	blank := fs.Int("i", -1, "goal index of the blank for compact lines (-1 for last)")
+	timeout := fs.Duration("timeout", 0, "give up on a puzzle after this long (0 for no limit)")
+	fs.Int64Var(&opts.MaxNodes, "max-nodes", 0, "give up on a puzzle after generating this many nodes (0 for no limit)")
+	maxMemory := fs.Int64("max-memory", 0, "memory budget in MiB per puzzle (0 for no limit)")
+	fs.Parse(args)
+	opts.MaxMemory = *maxMemory << 20
+
+	if *workers < 1 {
+		fail(fmt.Errorf("workers must be positive, got %d", *workers))
+	}
+	if *format != "csv" && *format != "json" {
+		fail(fmt.Errorf("unknown format %q (want csv or json)", *format))
+	}
+	// parallel-ida shares the CPUs with the other puzzles in flight.
+	opts.Workers = max(1, runtime.NumCPU() / *workers)
+
+	in, name := io.Reader(os.Stdin), "stdin"
+	if path := fs.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		in, name = f, path
+	}
+	instances, err := npuzzle.ReadInstances(in, *goalInput, *blank)
+	if err != nil {
+		fail(fmt.Errorf("%s: %w", name, err))
+	}
+
+	results := make([]chan batchRecord, len(instances))
+	for k := range results {
+		results[k] = make(chan batchRecord, 1)
+	}
+	jobs := make(chan int)
+	go func() {
+		for k := range instances {
+			jobs <- k
+		}
+		close(jobs)
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				results[k] <- solveInstance(context.Background(), opts, *timeout, k, instances[k])
+			}
+		}()
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	cw := csv.NewWriter(w)
+	enc := json.NewEncoder(w)
+	if *format == "csv" {
+		cw.Write(batchColumns)
+	}
+	failed := false
+	for _, result := range results {
+		rec := <-result
+		failed = failed || rec.Error != ""
+		if *format == "csv" {
+			cw.Write(rec.csv())
+			cw.Flush()
+		} else {
+			enc.Encode(rec)
+		}
+		w.Flush()
+	}
+	wg.Wait()
+	if failed {
+		os.Exit(1)
+	}
+}
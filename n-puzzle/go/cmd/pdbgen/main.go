@@ -0,0 +1,78 @@
+// Command pdbgen precomputes the pattern databases used by the n-puzzle
+// solver's pdb heuristic and writes them to the solver's cache directory.
+//
+//	go run ./cmd/pdbgen -n 15 -i -1 -pdb 6-6-3
+//	go run ./cmd/pdbgen -n 11 -cols 4
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"algorithms-solutions/n-puzzle/go/pdb"
+)
+
+func main() {
+	n := flag.Int("n", 15, "number of tiles, as on the first input line of the solver")
+	blank := flag.Int("i", -1, "goal index of the blank, as on the second input line (-1 for last)")
+	width := flag.Int("cols", 0, "board width, as the length of the first board row (default: square board)")
+	partition := flag.String("pdb", "", "pattern partition, e.g. 6-6-3 (default: groups of at most 6 tiles)")
+	dir := flag.String("dir", pdb.DefaultDir(), "output directory")
+	force := flag.Bool("force", false, "rebuild tables that already exist")
+	flag.Parse()
+
+	rows, cols := int(math.Sqrt(float64(*n+1))), *width
+	if cols == 0 {
+		cols = rows
+	}
+	if cols <= 0 || (*n+1)%cols != 0 {
+		fail(fmt.Errorf("n+1 = %d does not fill rows of width %d", *n+1, cols))
+	}
+	rows = (*n + 1) / cols
+	if *blank < -1 || *blank > *n {
+		fail(fmt.Errorf("blank index %d is outside -1..%d", *blank, *n))
+	}
+
+	goal := pdb.Goal(*n+1, *blank)
+	groups, err := pdb.Partition(goal, *partition)
+	if err != nil {
+		fail(err)
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		fail(err)
+	}
+
+	for _, tiles := range groups {
+		path := filepath.Join(*dir, pdb.FileName(rows, cols, goal, tiles))
+		if !*force {
+			if t, err := pdb.Open(path); err == nil {
+				ok := t.Matches(rows, cols, goal, tiles)
+				t.Close()
+				if ok {
+					fmt.Printf("%s: up to date\n", path)
+					continue
+				}
+			}
+		}
+
+		start := time.Now()
+		t, err := pdb.Build(context.Background(), rows, cols, goal, tiles)
+		if err != nil {
+			fail(err)
+		}
+		if err := t.Save(path); err != nil {
+			fail(err)
+		}
+		fmt.Printf("%s: %d entries in %s\n", path, len(t.Dist), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "pdbgen:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"algorithms-solutions/n-puzzle/go/npuzzle"
+)
+
+func writeInstance(w io.Writer, board npuzzle.Board, blank int) {
+	fmt.Fprintln(w, len(board)*len(board[0])-1)
+	fmt.Fprintln(w, blank)
+	for _, row := range board {
+		fmt.Fprintln(w, strings.Trim(fmt.Sprint(row), "[]"))
+	}
+}
+
+// generateMain implements "n-puzzle generate": it prints random solvable
+// instances in the solver's input format, separated by blank lines.
+func generateMain(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	n := fs.Int("n", 15, "number of tiles")
+	width := fs.Int("cols", 0, "board width (default: square board)")
+	blank := fs.Int("i", -1, "goal index of the blank (-1 for last)")
+	count := fs.Int("count", 1, "number of instances")
+	distance := fs.Int("distance", -1, "optimal solution length of every instance (-1: uniformly random boards)")
+	seed := fs.Int64("seed", 0, "random seed (default: time based)")
+	heuristicName := fs.String("heuristic", "manhattan", "heuristic of the solver measuring distances: "+strings.Join(npuzzle.HeuristicNames, ", "))
+	fs.Parse(args)
+
+	cols := *width
+	if cols == 0 {
+		cols = int(math.Sqrt(float64(*n + 1)))
+	}
+	if *n < 1 || cols <= 0 || (*n+1)%cols != 0 {
+		fail(fmt.Errorf("n+1 = %d does not fill rows of width %d", *n+1, cols))
+	}
+	if *blank < -1 || *blank > *n {
+		fail(fmt.Errorf("blank index %d is outside -1..%d", *blank, *n))
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	goal := npuzzle.SortedGoal((*n+1)/cols, cols, *blank)
+
+	gen, err := npuzzle.NewGenerator(goal, *distance, *heuristicName, rng)
+	if err != nil {
+		fail(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for k := 0; k < *count; k++ {
+		if k > 0 {
+			fmt.Fprintln(w)
+		}
+		board, err := gen.Next()
+		if err != nil {
+			w.Flush()
+			fail(err)
+		}
+		writeInstance(w, board, *blank)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -2,298 +2,209 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"math"
 	"os"
-	"strconv"
+	"runtime"
 	"strings"
 	"time"
-)
-
-type Board [][]int
-
-func findZero(board Board, n int) (int, int) {
-	for i := 0; i < n; i++ {
-		for j := 0; j < n; j++ {
-			if board[i][j] == 0 {
-				return i, j
-			}
-		}
-	}
-	return -1, -1
-}
-
-func calculateInversion(board Board, n int) int {
-	var flatList []int
-	for i := 0; i < n; i++ {
-		for j := 0; j < n; j++ {
-			if board[i][j] != 0 {
-				flatList = append(flatList, board[i][j])
-			}
-		}
-	}
 
-	inversions := 0
-	for i := 0; i < len(flatList); i++ {
-		for j := i + 1; j < len(flatList); j++ {
-			if flatList[i] > flatList[j] {
-				inversions++
-			}
-		}
-	}
-	return inversions
-}
-
-func isSolvable(initial Board, n int) bool {
-	inversions := calculateInversion(initial, n)
-
-	if n%2 != 0 {
-		return inversions%2 == 0
-	} else {
-		r, _ := findZero(initial, n)
-		rowFromBottom := n - r
-		return (inversions+rowFromBottom)%2 != 0
-	}
-}
+	"algorithms-solutions/n-puzzle/go/npuzzle"
+)
 
-func serialize(board Board) string {
-	var sb strings.Builder
-	for i := 0; i < len(board); i++ {
-		for j := 0; j < len(board[i]); j++ {
-			sb.WriteString(strconv.Itoa(board[i][j]))
-			sb.WriteString(",")
-		}
-		sb.WriteString(";")
+// parseNotation reads the -notation and -relative flags.
+func parseNotation(style, relative string) (npuzzle.Notation, error) {
+	var n npuzzle.Notation
+	switch style {
+	case "words":
+		n.Style = npuzzle.Words
+	case "letters":
+		n.Style = npuzzle.Letters
+	case "numbers":
+		n.Style = npuzzle.Numbers
+	default:
+		return n, fmt.Errorf("unknown notation %q (want words, letters or numbers)", style)
 	}
-	return sb.String()
-}
-
-func manhattanDistance(initial Board, n int, goalPosMap map[int][2]int) int {
-	distance := 0
-	for i := 0; i < n; i++ {
-		for j := 0; j < n; j++ {
-			currPos := initial[i][j]
-			if currPos == 0 {
-				continue
-			}
-			goalPos := goalPosMap[currPos]
-			goalI, goalJ := goalPos[0], goalPos[1]
-			distance += abs(i-goalI) + abs(j-goalJ)
-		}
+	switch relative {
+	case "tile":
+	case "blank":
+		n.Blank = true
+	default:
+		return n, fmt.Errorf("unknown -relative %q (want tile or blank)", relative)
 	}
-	return distance
+	return n, nil
 }
 
-func abs(x int) int {
-	if x < 0 {
-		return -x
+// writeSolution prints a solution in the solver's output format: the move
+// count, then the moves of path from start in notation n. Nothing is
+// written if path cannot be formatted.
+func writeSolution(w *bufio.Writer, start npuzzle.Board, path []npuzzle.Move, n npuzzle.Notation) error {
+	lines, err := n.Format(start, path)
+	if err != nil {
+		return err
 	}
-	return x
-}
-
-func swap(board Board, r, c, nr, nc int) Board {
-	newBoard := make(Board, len(board))
-	for i := range board {
-		newBoard[i] = make([]int, len(board[i]))
-		copy(newBoard[i], board[i])
+	fmt.Fprintln(w, len(path))
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
 	}
-	newBoard[r][c], newBoard[nr][nc] = newBoard[nr][nc], newBoard[r][c]
-	return newBoard
+	return nil
 }
 
-type SearchResult struct {
-	path []string
-	f    float64
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify":
+			verifyMain(os.Args[2:])
+			return
+		case "generate":
+			generateMain(os.Args[2:])
+			return
+		case "batch":
+			batchMain(os.Args[2:])
+			return
+		}
+	}
+	os.Exit(run())
 }
 
-func dfsIterative(board Board, path []string, g int, limit int, n int, target string, goalPosMap map[int][2]int, visited map[string]int) SearchResult {
-	boardTuple := serialize(board)
-	if prevG, exists := visited[boardTuple]; exists && prevG <= g {
-		return SearchResult{nil, math.Inf(1)}
-	}
-
-	visited[boardTuple] = g
-
-	h := manhattanDistance(board, n, goalPosMap)
-	f := g + h
-
-	if f > limit {
-		return SearchResult{nil, float64(f)}
-	}
-
-	if boardTuple == target {
-		return SearchResult{path, 0}
-	}
-
-	i, j := findZero(board, n)
-	moves := [][3]interface{}{
-		{"up", -1, 0},
-		{"down", 1, 0},
-		{"left", 0, -1},
-		{"right", 0, 1},
-	}
-	minF := math.Inf(1)
-
-	reserveMove := ""
-	if len(path) > 0 {
-		lastMove := path[len(path)-1]
-		switch lastMove {
-		case "up":
-			reserveMove = "down"
-		case "down":
-			reserveMove = "up"
-		case "left":
-			reserveMove = "right"
-		case "right":
-			reserveMove = "left"
-		}
-	}
-
-	for _, move := range moves {
-		moveStr := move[0].(string)
-		if moveStr == reserveMove {
-			continue
-		}
-
-		di := move[1].(int)
-		dj := move[2].(int)
-		ni, nj := i+di, j+dj
-
-		if 0 <= ni && ni < n && 0 <= nj && nj < n {
-			newBoard := swap(board, i, j, ni, nj)
-			newPath := make([]string, len(path)+1)
-			copy(newPath, path)
-			newPath[len(path)] = moveStr
-
-			result := dfsIterative(newBoard, newPath, g+1, limit, n, target, goalPosMap, visited)
-
-			if result.path != nil {
-				return result
-			}
-
-			if result.f < minF {
-				minF = result.f
+// run solves the puzzle on stdin and returns the exit status, so the
+// deferred closing of the trace log and the context run on every path.
+func run() int {
+	var opts npuzzle.Options
+	flag.StringVar(&opts.Heuristic, "heuristic", "manhattan", "heuristic: "+strings.Join(npuzzle.HeuristicNames, ", "))
+	flag.StringVar(&opts.PDB.Partition, "pdb", "", "pattern database partition, e.g. 6-6-3 (default: groups of at most 6 tiles)")
+	flag.StringVar(&opts.PDB.Dir, "pdb-dir", "", "directory caching pattern databases (default: user cache dir)")
+	flag.StringVar(&opts.Strategy, "strategy", "ida", "search strategy: "+strings.Join(npuzzle.StrategyNames, ", "))
+	flag.Float64Var(&opts.Epsilon, "epsilon", 0.5, "weighted A* bound: solutions are at most (1+epsilon) times optimal")
+	flag.IntVar(&opts.Workers, "workers", runtime.NumCPU(), "goroutines used by parallel-ida")
+	printStats := flag.Bool("stats", false, "print search statistics to stderr")
+	goalInput := flag.Bool("goal", false, "read an explicit goal board after the start board instead of the blank index line")
+	trace := flag.Bool("trace", false, "print every IDA* threshold (limit, nodes, time, branching factor) to stderr")
+	traceLog := flag.String("trace-log", "", "write thresholds and progress as JSON lines to this file instead")
+	progress := flag.Duration("progress", 0, "print a progress line at this interval during IDA* (e.g. 10s)")
+	timeout := flag.Duration("timeout", 0, "give up after this long (e.g. 30s; 0 for no limit)")
+	flag.Int64Var(&opts.MaxNodes, "max-nodes", 0, "give up after generating this many nodes (0 for no limit)")
+	maxMemory := flag.Int64("max-memory", 0, "memory budget in MiB for the search's tables and lists (0 for no limit)")
+	all := flag.Bool("all", false, "print every optimal solution (found with IDA*, -strategy is ignored)")
+	limit := flag.Int("k", 0, "print at most this many optimal solutions (implies -all)")
+	count := flag.Bool("count", false, "print the optimal length and the number of optimal solutions")
+	style := flag.String("notation", "words", "move notation: words (one direction per line), letters (UDLR on one line) or numbers (slid tiles on one line)")
+	relative := flag.String("relative", "tile", "directions name the move of the slid tile or of the blank")
+	anytime := flag.Bool("anytime", false, "find a solution fast, then shorten it until -timeout, reporting each improvement to stderr")
+	animate := flag.Bool("animate", false, "replay the solution on the terminal (stderr) before printing it")
+	delay := flag.Duration("delay", 500*time.Millisecond, "pause between moves of -animate")
+	step := flag.Bool("step", false, "step through -animate with keypresses instead of -delay")
+	flag.Parse()
+	opts.MaxMemory = *maxMemory << 20
+	notation, err := parseNotation(*style, *relative)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if (*animate || *step) && (*all || *limit > 0 || *count) {
+		fmt.Fprintln(os.Stderr, "-animate needs a single solution, not -all, -k or -count")
+		return 1
+	}
+
+	if *trace || *traceLog != "" || *progress > 0 {
+		opts.Trace = &npuzzle.Tracer{W: os.Stderr, Iterations: *trace || *traceLog != "", Interval: *progress}
+		if *traceLog != "" {
+			f, err := os.Create(*traceLog)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
 			}
+			defer f.Close()
+			opts.Trace.W, opts.Trace.JSON = f, true
 		}
 	}
 
-	return SearchResult{nil, minF}
-}
-
-func solve(initial Board, goal Board, n int) (interface{}, float64) {
-	startTime := time.Now()
-
-	if !isSolvable(initial, n) {
-		ms := float64(time.Since(startTime).Milliseconds())
-		return -1, ms
+	initialBoard, goal, err := npuzzle.ParseInput(os.Stdin, *goalInput)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
-	target := serialize(goal)
-	goalPosMap := make(map[int][2]int)
-	for i := 0; i < n; i++ {
-		for j := 0; j < n; j++ {
-			goalPosMap[goal[i][j]] = [2]int{i, j}
-		}
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
 	}
-
-	limit := manhattanDistance(initial, n, goalPosMap)
-	var solution interface{} = nil
-
-	for solution == nil {
-		visited := make(map[string]int)
-		result := dfsIterative(initial, []string{}, 0, limit, n, target, goalPosMap, visited)
-
-		if result.path != nil {
-			solution = result.path
-		} else if math.IsInf(result.f, 1) {
-			solution = -1
-			break
-		} else {
-			limit = int(result.f)
+	var solution npuzzle.Solution
+	var set *npuzzle.SolutionSet
+	if *anytime {
+		solution, err = npuzzle.SolveAnytime(ctx, initialBoard, goal, opts, func(imp npuzzle.Improvement) {
+			fmt.Fprintf(os.Stderr, "# anytime length=%d phase=%q time_ms=%.3f\n", len(imp.Moves), imp.Phase,
+				float64(imp.Elapsed.Nanoseconds())/1e6)
+		})
+	} else if *all || *limit > 0 || *count {
+		opts.Strategy = "ida"
+		set, err = npuzzle.CountSolutions(ctx, initialBoard, goal, opts)
+		if set != nil {
+			solution.Stats = set.Stats
 		}
+	} else {
+		solution, err = npuzzle.Solve(ctx, initialBoard, goal, opts)
 	}
-
-	elapsedMs := float64(time.Since(startTime).Milliseconds())
-	return solution, elapsedMs
-}
-
-func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	scanner.Scan()
-	n, _ := strconv.Atoi(scanner.Text())
-
-	scanner.Scan()
-	i, _ := strconv.Atoi(scanner.Text())
-
-	tilesNum := int(math.Sqrt(float64(n + 1)))
-
-	goalBoard := make([]int, 0, n+1)
-	for k := 1; k <= n; k++ {
-		goalBoard = append(goalBoard, k)
-	}
-	goalBoard = append(goalBoard, 0)
-
-	targetZeroIdx := i
-	if i == -1 {
-		targetZeroIdx = n
-	}
-
-	if 0 <= targetZeroIdx && targetZeroIdx < len(goalBoard) {
-		goalBoard[len(goalBoard)-1], goalBoard[targetZeroIdx] = goalBoard[targetZeroIdx], goalBoard[len(goalBoard)-1]
+	var budgetErr *npuzzle.BudgetError
+	switch {
+	case errors.Is(err, npuzzle.ErrUnsolvable), errors.As(err, &budgetErr):
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
 
-	goal := make(Board, tilesNum)
-	for k := 0; k < tilesNum; k++ {
-		goal[k] = goalBoard[k*tilesNum : (k+1)*tilesNum]
+	if *printStats {
+		stats := solution.Stats
+		fmt.Fprintf(os.Stderr, "# strategy=%s heuristic=%s expanded=%d generated=%d max_frontier=%d iterations=%d memory=%d time_ms=%.3f\n",
+			opts.Strategy, opts.Heuristic, stats.Expanded, stats.Generated, stats.MaxFrontier, stats.Iterations, stats.Memory,
+			float64(stats.Elapsed.Nanoseconds())/1e6)
 	}
-
-	var initial []int
-	for k := 0; k < tilesNum; k++ {
-		if !scanner.Scan() {
-			break
-		}
-		line := scanner.Text()
-		parts := strings.Fields(line)
-		if len(parts) == 0 {
-			break
-		}
-		for _, part := range parts {
-			val, _ := strconv.Atoi(part)
-			initial = append(initial, val)
-		}
-	}
-
-	initialBoard := make(Board, tilesNum)
-	for k := 0; k < tilesNum; k++ {
-		initialBoard[k] = make([]int, tilesNum)
-		for l := 0; l < tilesNum; l++ {
-			initialBoard[k][l] = initial[k*tilesNum+l]
-		}
+	if budgetErr != nil {
+		fmt.Fprintln(os.Stderr, "# gave up:", budgetErr)
+		return 3
 	}
-
-	solution, _ := solve(initialBoard, goal, tilesNum)
-
-	switch v := solution.(type) {
-	case int:
-		if v == -1 {
-			fmt.Println("-1")
-		}
-	case []string:
-		fmt.Println(len(v))
-		for _, step := range v {
-			switch step {
-			case "right":
-				fmt.Println("left")
-			case "left":
-				fmt.Println("right")
-			case "up":
-				fmt.Println("down")
-			case "down":
-				fmt.Println("up")
+	if err != nil {
+		fmt.Println("-1")
+		return 0
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	switch {
+	case *count:
+		fmt.Fprintln(w, set.Length)
+		fmt.Fprintln(w, set.Count)
+	case set != nil:
+		printed := 0
+		set.Each(func(path []npuzzle.Move) bool {
+			if printed > 0 {
+				fmt.Fprintln(w)
 			}
-		}
+			if err = writeSolution(w, initialBoard, path, notation); err != nil {
+				return false
+			}
+			printed++
+			return *limit <= 0 || printed < *limit
+		})
 	default:
-		fmt.Println("-1")
+		if *animate || *step {
+			a, restore, err := newAnimation(os.Stderr, *delay, *step)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			a.play(initialBoard, solution.Moves)
+			restore()
+		}
+		err = writeSolution(w, initialBoard, solution.Moves, notation)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
+	return 0
 }
@@ -0,0 +1,132 @@
+package npuzzle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// move returns the blank move named by a word or letter in notation n: the
+// tile moving left is the blank moving right unless n.Blank is set.
+func (n Notation) move(name string) (Move, bool) {
+	for _, m := range allMoves {
+		named := m
+		if !n.Blank {
+			named = m.Reverse()
+		}
+		if n.Style == Words && named.String() == name || n.Style == Letters && string(named.Letter()) == name {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// Answer is a solver answer: the blank moves, or Unsolvable for "-1". An
+// answer in numbers holds the slid tiles instead, which Replay turns into
+// Moves. line and col locate each move in the input for error messages.
+type Answer struct {
+	Unsolvable bool
+	Moves      []Move
+	Tiles      []int
+	line, col  []int
+}
+
+// Len returns the number of moves of a.
+func (a *Answer) Len() int {
+	return max(len(a.Moves), len(a.Tiles))
+}
+
+// ParseAnswer reads a solver answer written in notation n: the move count,
+// then that many moves, or -1 alone. Letters may be run together ("LURD").
+func ParseAnswer(r io.Reader, n Notation) (*Answer, error) {
+	p := &parser{scanner: bufio.NewScanner(r)}
+	count, col, err := p.number("move count")
+	if err != nil {
+		return nil, err
+	}
+	if count < -1 {
+		return nil, p.errorf(col, "move count must be -1 or at least 0, got %d", count)
+	}
+	countLine := p.line
+
+	a := &Answer{Unsolvable: count == -1}
+	for {
+		tokens, ok := p.next()
+		if !ok {
+			break
+		}
+		for _, tok := range tokens {
+			switch n.Style {
+			case Words:
+				m, ok := n.move(tok.text)
+				if !ok {
+					return nil, p.errorf(tok.col, "unknown move %q (want up, down, left or right)", tok.text)
+				}
+				a.Moves = append(a.Moves, m)
+			case Letters:
+				for k := range tok.text {
+					m, ok := n.move(tok.text[k : k+1])
+					if !ok {
+						return nil, p.errorf(tok.col+k, "unknown move %q (want U, D, L or R)", tok.text[k:k+1])
+					}
+					a.Moves = append(a.Moves, m)
+					a.line = append(a.line, p.line)
+					a.col = append(a.col, tok.col+k)
+				}
+				continue
+			case Numbers:
+				tile, err := strconv.Atoi(tok.text)
+				if err != nil || tile < 1 {
+					return nil, p.errorf(tok.col, "tile must be a positive integer, got %q", tok.text)
+				}
+				a.Tiles = append(a.Tiles, tile)
+			}
+			a.line = append(a.line, p.line)
+			a.col = append(a.col, tok.col)
+		}
+	}
+	if err := p.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if want := max(count, 0); a.Len() != want {
+		return nil, &ParseError{Line: countLine, Col: col, Msg: fmt.Sprintf("move count is %d but %d moves follow", count, a.Len())}
+	}
+	return a, nil
+}
+
+// Replay applies the moves to start and reports whether they end on goal. A
+// move that would push the blank off the board, or a tile that is not next
+// to the blank, is an error. The tiles of an answer in numbers are turned
+// into Moves as far as they go.
+func (a *Answer) Replay(start, goal Board) (bool, error) {
+	s := newState(start)
+	if a.Tiles != nil {
+		a.Moves = a.Moves[:0]
+	}
+	for k := 0; k < a.Len(); k++ {
+		var c int
+		var what string
+		if a.Tiles != nil {
+			tile := a.Tiles[k]
+			c, what = -1, fmt.Sprintf("(tile %d) is not next to the blank", tile)
+			for _, m := range allMoves {
+				if t := s.target(m); t >= 0 && int(s.tiles[t]) == tile {
+					c = t
+					a.Moves = append(a.Moves, m)
+				}
+			}
+		} else {
+			c, what = s.target(a.Moves[k]), fmt.Sprintf("(%s) has no tile to slide", a.Moves[k].Reverse())
+		}
+		if c < 0 {
+			err := &ParseError{Msg: fmt.Sprintf("move %d %s", k+1, what)}
+			if k < len(a.line) {
+				err.Line, err.Col = a.line[k], a.col[k]
+			}
+			return false, err
+		}
+		s.slide(c)
+	}
+	return s.equals(newState(goal)), nil
+}
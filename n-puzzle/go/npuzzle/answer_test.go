@@ -0,0 +1,52 @@
+package npuzzle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	start := Board{{1, 2, 3}, {4, 5, 6}, {7, 0, 8}}
+	goal := Board{{1, 2, 3}, {4, 5, 6}, {7, 8, 0}}
+	tests := []struct {
+		name     string
+		answer   string
+		notation Notation
+		reached  bool
+		err      string
+	}{
+		{"solution", "1\nleft\n", Notation{}, true, ""},
+		{"moves on one line", "3\nleft right left", Notation{}, true, ""},
+		{"wrong direction", "1\nright\n", Notation{}, false, ""},
+		{"off the board", "2\nleft\nleft\n", Notation{}, false, "line 3, column 1: move 2 (left) has no tile to slide"},
+		{"count mismatch", "2\nleft\n", Notation{}, false, "line 1, column 1: move count is 2 but 1 moves follow"},
+		{"unknown move", "1\nwest\n", Notation{}, false, "line 2, column 1: unknown move \"west\" (want up, down, left or right)"},
+		{"moves after -1", "-1\nleft\n", Notation{}, false, "line 1, column 1: move count is -1 but 1 moves follow"},
+		{"blank words", "1\nright\n", Notation{Blank: true}, true, ""},
+		{"letters", "3\nLRL\n", Notation{Style: Letters}, true, ""},
+		{"unknown letter", "3\nLXL\n", Notation{Style: Letters}, false, "line 2, column 2: unknown move \"X\" (want U, D, L or R)"},
+		{"numbers", "3\n8 8 8\n", Notation{Style: Numbers}, true, ""},
+		{"tile not next to the blank", "2\n8 3\n", Notation{Style: Numbers}, false, "line 2, column 3: move 2 (tile 3) is not next to the blank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := ParseAnswer(strings.NewReader(tt.answer), tt.notation)
+			reached := false
+			if err == nil {
+				reached, err = a.Replay(start, goal)
+			}
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reached != tt.reached {
+				t.Errorf("reached = %v, want %v", reached, tt.reached)
+			}
+		})
+	}
+}
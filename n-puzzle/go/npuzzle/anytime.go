@@ -0,0 +1,211 @@
+package npuzzle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Improvement reports a solution shorter than every earlier one of
+// SolveAnytime: its moves, the phase that found it and the time since the
+// start.
+type Improvement struct {
+	Moves   []Move
+	Phase   string
+	Elapsed time.Duration
+}
+
+// anytimeWindows are the window lengths smoothed after the construction, and
+// windowBudget bounds the search that replaces one window.
+var (
+	anytimeWindows = []int{8, 16, 24, 32}
+	windowBudget   = searchBudget{maxNodes: 1 << 18, maxBytes: 1 << 20}
+)
+
+// anytimeWeights are the weights of the bounded weighted A* runs that
+// follow. The last is plain A*: if it finds nothing shorter, the best
+// solution is optimal.
+var anytimeWeights = []float64{3, 2, 1.5, 1.25, 1}
+
+// defaultAnytimeMemory bounds each weighted A* run when Options.MaxMemory
+// is 0.
+const defaultAnytimeMemory = 1 << 30
+
+// SolveAnytime finds a solution quickly and keeps shortening it until ctx is
+// done or no phase is left, calling improved (if not nil) with each shorter
+// one. The phases are a row by row construction, removing loops, replacing
+// windows of the path by optimal IDA* paths between their end boards, and
+// weighted A* runs with the heuristic of opts that only look for solutions
+// shorter than the best one, each success being smoothed by windows again.
+// MaxNodes and MaxMemory bound each of these runs.
+//
+// The best solution is returned without error when ctx ends the search; its
+// Stats.LowerBound equals its length when it is known to be optimal. Boards
+// with a single row or column are passed to Solve.
+func SolveAnytime(ctx context.Context, start, goal Board, opts Options, improved func(Improvement)) (Solution, error) {
+	if err := checkBoards(start, goal); err != nil {
+		return Solution{}, err
+	}
+	if len(start) < 2 || len(start[0]) < 2 {
+		return Solve(ctx, start, goal, opts)
+	}
+	at := &anytime{ctx: ctx, start: start, began: time.Now(), improved: improved}
+	h, _, err := opts.build(ctx, start, goal)
+	if err != nil {
+		return Solution{Stats: Stats{Elapsed: time.Since(at.began)}}, err
+	}
+	if !IsSolvable(start, goal) {
+		return Solution{Stats: Stats{Elapsed: time.Since(at.began)}}, ErrUnsolvable
+	}
+	s := newState(start)
+	s.setHeuristic(h)
+	at.stats.LowerBound = s.h
+
+	path, err := construct(start, goal)
+	if err != nil {
+		return Solution{}, err
+	}
+	at.best = path
+	at.report("construct")
+	at.offer(shortcut(start, at.best), "shortcut")
+	at.smoothAll()
+
+	budget := opts.budget()
+	if budget.maxBytes == 0 {
+		budget.maxBytes = defaultAnytimeMemory
+	}
+	for _, weight := range anytimeWeights {
+		if ctx.Err() != nil || at.stats.LowerBound == len(at.best) {
+			break
+		}
+		sol, err := search(ctx, start, goal, h, bestFirst{weight: weight, bound: len(at.best)}, budget)
+		at.add(sol.Stats)
+		phase := fmt.Sprintf("wastar %g", weight)
+		if weight == 1 {
+			phase = "astar"
+			var budgetErr *BudgetError
+			switch {
+			case err == nil:
+				at.stats.LowerBound = len(sol.Moves)
+			case errors.Is(err, ErrUnsolvable):
+				// Nothing is shorter than the best solution.
+				at.stats.LowerBound = len(at.best)
+			case errors.As(err, &budgetErr):
+				at.stats.LowerBound = max(at.stats.LowerBound, budgetErr.LowerBound)
+			}
+		}
+		if err == nil && at.offer(sol.Moves, phase) {
+			at.smoothAll()
+		}
+	}
+	at.stats.Elapsed = time.Since(at.began)
+	return Solution{Moves: at.best, Stats: at.stats}, nil
+}
+
+// anytime holds the best solution of SolveAnytime and the statistics of all
+// its searches.
+type anytime struct {
+	ctx      context.Context
+	start    Board
+	began    time.Time
+	best     []Move
+	stats    Stats
+	improved func(Improvement)
+}
+
+func (at *anytime) report(phase string) {
+	if at.improved != nil {
+		at.improved(Improvement{Moves: at.best, Phase: phase, Elapsed: time.Since(at.began)})
+	}
+}
+
+// offer makes path the best solution if it is shorter, reporting whether it
+// was.
+func (at *anytime) offer(path []Move, phase string) bool {
+	if len(path) >= len(at.best) {
+		return false
+	}
+	at.best = path
+	at.report(phase)
+	return true
+}
+
+func (at *anytime) add(stats Stats) {
+	at.stats.Expanded += stats.Expanded
+	at.stats.Generated += stats.Generated
+	at.stats.Iterations += stats.Iterations
+	at.stats.MaxFrontier = max(at.stats.MaxFrontier, stats.MaxFrontier)
+	at.stats.Memory = max(at.stats.Memory, stats.Memory)
+}
+
+// smoothAll smooths the best solution with every window length until it
+// stops getting shorter.
+func (at *anytime) smoothAll() {
+	for _, w := range anytimeWindows {
+		for at.smooth(w) {
+		}
+	}
+}
+
+// smooth cuts the best solution into windows of w moves, starting at move 0
+// and again at move w/2, and replaces each window by an optimal path
+// between its end boards if IDA* with the Manhattan distance towards the
+// later board finds one within windowBudget. It reports whether the
+// solution got shorter.
+func (at *anytime) smooth(w int) bool {
+	shorter := false
+	for _, offset := range []int{0, w / 2} {
+		path := at.best
+		s := newState(at.start)
+		out := append([]Move(nil), path[:min(offset, len(path))]...)
+		for _, m := range out {
+			s.slide(s.target(m))
+		}
+		for i := len(out); i < len(path); i += w {
+			if at.ctx.Err() != nil {
+				return false
+			}
+			window := path[i:min(i+w, len(path))]
+			end := s.clone()
+			for _, m := range window {
+				end.slide(end.target(m))
+			}
+			endBoard := end.board()
+			sol, err := search(at.ctx, s.board(), endBoard, newManhattan(endBoard), idaStar{}, windowBudget)
+			at.add(sol.Stats)
+			if err == nil && len(sol.Moves) < len(window) {
+				window = sol.Moves
+			}
+			out = append(out, window...)
+			s = end
+		}
+		if at.offer(out, fmt.Sprintf("window %d", w)) {
+			shorter = true
+		}
+	}
+	return shorter
+}
+
+// shortcut drops the loops of path: when a board comes up again, the moves
+// since its first visit are cut.
+func shortcut(start Board, path []Move) []Move {
+	s := newState(start)
+	keys := []uint64{s.key}
+	visited := map[uint64]int{s.key: 0}
+	var out []Move
+	for _, m := range path {
+		s.slide(s.target(m))
+		if k, ok := visited[s.key]; ok {
+			for _, key := range keys[k+1:] {
+				delete(visited, key)
+			}
+			keys, out = keys[:k+1], out[:k]
+			continue
+		}
+		out = append(out, m)
+		keys = append(keys, s.key)
+		visited[s.key] = len(out)
+	}
+	return out
+}
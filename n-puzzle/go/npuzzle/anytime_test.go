@@ -0,0 +1,57 @@
+package npuzzle
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSolveAnytime(t *testing.T) {
+	goal := SortedGoal(3, 3, -1)
+	start := Board{{8, 6, 7}, {2, 5, 4}, {3, 0, 1}}
+	solution, err := SolveAnytime(context.Background(), start, goal, Options{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solution.Moves) != 31 || solution.Stats.LowerBound != 31 {
+		t.Errorf("%d moves with lower bound %d, want 31 proven optimal", len(solution.Moves), solution.Stats.LowerBound)
+	}
+
+	goal = SortedGoal(6, 6, -1)
+	start = randomBoard(goal, rand.New(rand.NewSource(1)))
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	var lengths []int
+	solution, err = SolveAnytime(ctx, start, goal, Options{}, func(imp Improvement) {
+		lengths = append(lengths, len(imp.Moves))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reached, err := (&Answer{Moves: solution.Moves}).Replay(start, goal); !reached || err != nil {
+		t.Fatal("6x6 solution does not reach the goal")
+	}
+	for k := 1; k < len(lengths); k++ {
+		if lengths[k] >= lengths[k-1] {
+			t.Errorf("improvements %v are not decreasing", lengths)
+			break
+		}
+	}
+	if len(lengths) == 0 || lengths[len(lengths)-1] != len(solution.Moves) {
+		t.Errorf("improvements %v do not end with the returned %d moves", lengths, len(solution.Moves))
+	}
+}
+
+// TestSolveAnytimeLowerBound checks a board where plain A* shortens the
+// solution: the lower bound it proves is the new length, not the old one.
+func TestSolveAnytimeLowerBound(t *testing.T) {
+	start := Board{{9, 3, 6, 0, 1}, {2, 5, 4, 8, 7}}
+	solution, err := SolveAnytime(context.Background(), start, SortedGoal(2, 5, -1), Options{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solution.Stats.LowerBound > len(solution.Moves) {
+		t.Errorf("%d moves with lower bound %d", len(solution.Moves), solution.Stats.LowerBound)
+	}
+}
@@ -0,0 +1,115 @@
+// Package npuzzle solves sliding puzzles on rectangular boards with
+// heuristic search: IDA* (optionally parallel), A*, weighted A* and
+// bidirectional search, with Manhattan, linear conflict, walking distance and
+// additive pattern database heuristics.
+package npuzzle
+
+// Board holds the tiles row by row, 0 being the blank.
+type Board [][]int
+
+func findZero(board Board) (int, int) {
+	for i, row := range board {
+		for j, tile := range row {
+			if tile == 0 {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// calculateInversion counts the pairs of cells of board, blank included,
+// whose tiles appear in the opposite order in goal.
+func calculateInversion(board, goal Board) int {
+	cellOf := goalCells(goal)
+	var flatList []int
+	for _, row := range board {
+		for _, tile := range row {
+			flatList = append(flatList, cellOf[tile])
+		}
+	}
+
+	inversions := 0
+	for i := 0; i < len(flatList); i++ {
+		for j := i + 1; j < len(flatList); j++ {
+			if flatList[i] > flatList[j] {
+				inversions++
+			}
+		}
+	}
+	return inversions
+}
+
+// IsSolvable compares the parity of the permutation from initial to goal with
+// the parity of the blank's grid distance between the two boards. Every move
+// swaps the blank with a neighbour, changing both by one, so they stay equal
+// exactly on the boards reachable from goal.
+func IsSolvable(initial, goal Board) bool {
+	r, c := findZero(initial)
+	goalR, goalC := findZero(goal)
+	return (calculateInversion(initial, goal)+abs(r-goalR)+abs(c-goalC))%2 == 0
+}
+
+// Apply makes blank move m on b in place and returns the tile that slid,
+// or false (leaving b alone) if m would take the blank off the board.
+func (b Board) Apply(m Move) (int, bool) {
+	i, j := findZero(b)
+	ni, nj := i+moves[m].di, j+moves[m].dj
+	if ni < 0 || ni >= len(b) || nj < 0 || nj >= len(b[ni]) {
+		return 0, false
+	}
+	tile := b[ni][nj]
+	b[i][j], b[ni][nj] = tile, 0
+	return tile, true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Move is a move of the blank; the tile next to it slides the opposite way.
+type Move uint8
+
+const (
+	Up Move = iota
+	Down
+	Left
+	Right
+)
+
+// noMove stands for the missing last move at the root of a search. Its
+// reverse is no move either, so it never prunes a successor.
+const noMove Move = 0xff
+
+// allMoves lists the moves in an order where m^1 is the reverse of m.
+var allMoves = [...]Move{Up, Down, Left, Right}
+
+func (m Move) String() string {
+	return moves[m].name
+}
+
+// Reverse returns the move that undoes m, which is also the direction the
+// slid tile moves in.
+func (m Move) Reverse() Move {
+	return m ^ 1
+}
+
+// Letter returns the first letter of m's name in upper case.
+func (m Move) Letter() byte {
+	return moves[m].letter
+}
+
+// moves holds the name, letter and grid offset of every move.
+var moves = [...]struct {
+	name   string
+	letter byte
+	di, dj int
+}{
+	{"up", 'U', -1, 0},
+	{"down", 'D', 1, 0},
+	{"left", 'L', 0, -1},
+	{"right", 'R', 0, 1},
+}
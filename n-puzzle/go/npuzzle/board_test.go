@@ -0,0 +1,212 @@
+package npuzzle
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+)
+
+// distances returns the true distance to goal of every board reachable from
+// it, keyed by the exact state key, by breadth-first search.
+func distances(goal Board) map[uint64]int {
+	s := newState(goal)
+	cells := len(s.tiles)
+	root := make([]uint16, cells)
+	s.save(root)
+	dist := map[uint64]int{s.key: 0}
+	queue := [][]uint16{root}
+	for len(queue) > 0 {
+		tiles := queue[0]
+		queue = queue[1:]
+		s.load(tiles)
+		d := dist[s.key]
+		for _, m := range allMoves {
+			c := s.target(m)
+			if c < 0 {
+				continue
+			}
+			prev := s.blank
+			s.slide(c)
+			if _, ok := dist[s.key]; !ok {
+				dist[s.key] = d + 1
+				next := make([]uint16, cells)
+				s.save(next)
+				queue = append(queue, next)
+			}
+			s.slide(prev)
+		}
+	}
+	return dist
+}
+
+// permute calls visit with every permutation of tiles, in place.
+func permute(tiles []int, k int, visit func()) {
+	if k == len(tiles) {
+		visit()
+		return
+	}
+	for i := k; i < len(tiles); i++ {
+		tiles[k], tiles[i] = tiles[i], tiles[k]
+		permute(tiles, k+1, visit)
+		tiles[k], tiles[i] = tiles[i], tiles[k]
+	}
+}
+
+func TestIsSolvable(t *testing.T) {
+	for _, shape := range [][2]int{{2, 2}, {2, 3}, {3, 3}} {
+		rows, cols := shape[0], shape[1]
+		for _, blank := range []int{-1, 0} {
+			goal := SortedGoal(rows, cols, blank)
+			dist := distances(goal)
+			tiles := make([]int, rows*cols)
+			for k := range tiles {
+				tiles[k] = k
+			}
+			s, buf := newState(goal), make([]uint16, rows*cols)
+			solvable := 0
+			permute(tiles, 0, func() {
+				for c, tile := range tiles {
+					buf[c] = uint16(tile)
+				}
+				s.load(buf)
+				_, reachable := dist[s.key]
+				board := toBoard(tiles, cols)
+				if IsSolvable(board, goal) != reachable {
+					t.Fatalf("%v -> %v: IsSolvable = %v, breadth-first search says %v", board, goal, !reachable, reachable)
+				}
+				if reachable {
+					solvable++
+				}
+			})
+			if solvable != len(dist) || 2*solvable != factorial(rows*cols) {
+				t.Errorf("%dx%d: %d solvable boards, want half of %d", rows, cols, solvable, factorial(rows*cols))
+			}
+		}
+	}
+}
+
+func factorial(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * factorial(n-1)
+}
+
+// TestHeuristicsAdmissible checks on random 8-puzzle boards that no
+// heuristic exceeds the true distance, so IDA* and A* return optimal
+// solutions, and that slide keeps every estimate current. Apart from pattern
+// databases, whose tables take the best blank cell, the heuristics are also
+// consistent: a move changes them by one at most.
+func TestHeuristicsAdmissible(t *testing.T) {
+	goal := SortedGoal(3, 3, -1)
+	dist := distances(goal)
+	hs := map[string]heuristic{}
+	for _, name := range HeuristicNames {
+		h, err := newHeuristic(context.Background(), name, goal, PDBOptions{Dir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs[name] = h
+	}
+
+	admissible := func(seed int64) bool {
+		board := randomBoard(goal, rand.New(rand.NewSource(seed)))
+		d := dist[newState(board).key]
+		for name, h := range hs {
+			s := newState(board)
+			s.setHeuristic(h)
+			if s.h > d {
+				t.Logf("%v: %s estimate %d exceeds distance %d", board, name, s.h, d)
+				return false
+			}
+			for _, m := range allMoves {
+				c := s.target(m)
+				if c < 0 {
+					continue
+				}
+				h0, prev := s.h, s.blank
+				s.slide(c)
+				if abs(s.h-h0) > 1 && name != "pdb" || s.h != h.Estimate(s.clone()) {
+					t.Logf("%v: %s changes from %d to %d (estimate %d) on %s", board, name, h0, s.h, h.Estimate(s.clone()), m)
+					return false
+				}
+				s.slide(prev)
+			}
+		}
+		return true
+	}
+	if err := quick.Check(admissible, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSolveOptimal solves an 8-puzzle corpus, with true distances from
+// breadth-first search, with every heuristic and the optimal strategies.
+func TestSolveOptimal(t *testing.T) {
+	corpus := []struct {
+		tiles   []int
+		optimal int
+	}{
+		{[]int{4, 1, 3, 0, 2, 6, 7, 5, 8}, 5},
+		{[]int{0, 3, 5, 1, 2, 6, 4, 7, 8}, 10},
+		{[]int{4, 3, 6, 2, 5, 8, 1, 0, 7}, 15},
+		{[]int{7, 2, 3, 8, 5, 6, 0, 1, 4}, 20},
+		{[]int{5, 6, 2, 3, 0, 8, 1, 7, 4}, 22},
+		{[]int{0, 1, 6, 2, 7, 5, 3, 8, 4}, 24},
+		{[]int{1, 2, 7, 3, 4, 5, 6, 8, 0}, 26},
+		{[]int{3, 7, 4, 6, 5, 2, 0, 8, 1}, 28},
+		{[]int{6, 4, 7, 8, 2, 5, 3, 1, 0}, 30},
+		{[]int{8, 6, 7, 2, 5, 4, 3, 0, 1}, 31},
+		{[]int{6, 4, 7, 8, 5, 0, 3, 2, 1}, 31},
+	}
+	goal := SortedGoal(3, 3, -1)
+	dir := t.TempDir()
+	for _, tt := range corpus {
+		start := toBoard(tt.tiles, 3)
+		for _, heuristic := range HeuristicNames {
+			for _, strategy := range []string{"ida", "astar"} {
+				opts := Options{Heuristic: heuristic, PDB: PDBOptions{Dir: dir}, Strategy: strategy}
+				solution, err := Solve(context.Background(), start, goal, opts)
+				if err != nil {
+					t.Fatalf("%v %s/%s: %v", tt.tiles, heuristic, strategy, err)
+				}
+				if len(solution.Moves) != tt.optimal {
+					t.Errorf("%v %s/%s: %d moves, want %d", tt.tiles, heuristic, strategy, len(solution.Moves), tt.optimal)
+				}
+			}
+		}
+	}
+}
+
+// TestPatternDatabaseShared checks that pattern databases for the same goal
+// share the tables this process opened and that saving leaves no temporary
+// files behind.
+func TestPatternDatabaseShared(t *testing.T) {
+	goal := SortedGoal(3, 3, -1)
+	dir := t.TempDir()
+	a, err := newPatternDatabase(context.Background(), goal, PDBOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newPatternDatabase(context.Background(), goal, PDBOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := range a.tables {
+		if a.tables[k] != b.tables[k] {
+			t.Errorf("table %d was loaded twice", k)
+		}
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if filepath.Ext(f.Name()) != ".pdb" {
+			t.Errorf("unexpected file %s", f.Name())
+		}
+	}
+}
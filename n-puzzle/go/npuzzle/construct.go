@@ -0,0 +1,248 @@
+package npuzzle
+
+import "errors"
+
+// constructor solves a puzzle the way people do: row by row from the top
+// until two rows are left, then column by column from the left until a 2x2
+// square is left, fixing every cell once it holds its goal tile. Each tile is
+// routed by a breadth-first search over the cells of the blank and the tile
+// that never enters a fixed cell. The last two tiles of a row (or column) go
+// in together: the second one is parked in the first one's cell, the first
+// one next to it, and two blank moves turn both into place. The solution is
+// far from optimal, but found in time polynomial in the board size.
+type constructor struct {
+	s      *state
+	goal   []int
+	fixed  []bool
+	path   []Move
+	parent []int32
+	move   []Move
+	seen   []uint32
+	gen    uint32
+	queue  []int32
+}
+
+var (
+	errNoConstruction = errors.New("board has fewer than two rows or columns")
+	errUnreachable    = errors.New("construct: goal cell cannot be reached")
+)
+
+// construct returns a solution from start to goal. The goal is first moved
+// to one with the blank in the bottom right corner, which the row and
+// column phases need, and the moves back are appended.
+func construct(start, goal Board) ([]Move, error) {
+	g := newState(goal)
+	if g.rows < 2 || g.cols < 2 {
+		return nil, errNoConstruction
+	}
+	var tail []Move
+	for g.blank/g.cols < g.rows-1 {
+		tail = append(tail, Down)
+		g.slide(g.target(Down))
+	}
+	for g.blank%g.cols < g.cols-1 {
+		tail = append(tail, Right)
+		g.slide(g.target(Right))
+	}
+
+	s := newState(start)
+	cells := len(s.tiles)
+	cs := &constructor{
+		s:     s,
+		goal:  g.tiles,
+		fixed: make([]bool, cells),
+	}
+	rows, cols := s.rows, s.cols
+	cell := func(i, j int) int { return i*cols + j }
+
+	for i := 0; i < rows-2; i++ {
+		for j := 0; j < cols-2; j++ {
+			if err := cs.place(cell(i, j)); err != nil {
+				return nil, err
+			}
+		}
+		if err := cs.placePair(cell(i, cols-2), cell(i, cols-1), cell(i+1, cols-2), cell(i+2, cols-1), Left, Down); err != nil {
+			return nil, err
+		}
+	}
+	for j := 0; j < cols-2; j++ {
+		if err := cs.placePair(cell(rows-2, j), cell(rows-1, j), cell(rows-2, j+1), cell(rows-1, j+2), Up, Right); err != nil {
+			return nil, err
+		}
+	}
+	if !cs.rotate([]int{cell(rows-2, cols-2), cell(rows-2, cols-1), cell(rows-1, cols-1), cell(rows-1, cols-2)}) {
+		return nil, ErrUnsolvable
+	}
+
+	for k := len(tail) - 1; k >= 0; k-- {
+		cs.apply(tail[k].Reverse())
+	}
+	return cs.path, nil
+}
+
+func (cs *constructor) apply(m Move) {
+	cs.s.slide(cs.s.target(m))
+	cs.path = append(cs.path, m)
+}
+
+// place moves the goal tile of cell c there and fixes it.
+func (cs *constructor) place(c int) error {
+	if err := cs.route(cs.goal[c], c); err != nil {
+		return err
+	}
+	cs.fixed[c] = true
+	return nil
+}
+
+// placePair fixes the goal tiles of cells a and b, where b is the last cell
+// of its row or column and park the cell next to a on the other side. The
+// tile of b is routed to a and the tile of a to park, then the blank is
+// brought to b and makes the moves first (pushing the tile of b home) and
+// second (pulling the tile of a up from park). Once a is fixed, b is a dead
+// end: the tile of a cannot leave it, nor can the blank when the tile of a
+// sits in the cell between b and pin. In those cases the tile of a is first
+// moved out to pin and held there while the tile of b is routed again.
+func (cs *constructor) placePair(a, b, park, pin int, first, second Move) error {
+	tileA, tileB := cs.goal[a], cs.goal[b]
+	s := cs.s
+	if s.tiles[a] != tileA || s.tiles[b] != tileB {
+		if err := cs.route(tileB, a); err != nil {
+			return err
+		}
+		if between := (b + pin) / 2; s.tiles[b] == tileA || s.blank == b && s.tiles[between] == tileA {
+			if err := cs.route(tileA, pin); err != nil {
+				return err
+			}
+			cs.fixed[pin] = true
+			if err := cs.route(tileB, a); err != nil {
+				return err
+			}
+			cs.fixed[pin] = false
+		}
+		cs.fixed[a] = true
+		if err := cs.route(tileA, park); err != nil {
+			return err
+		}
+		cs.fixed[park] = true
+		if err := cs.routeBlank(b); err != nil {
+			return err
+		}
+		cs.fixed[a], cs.fixed[park] = false, false
+		cs.apply(first)
+		cs.apply(second)
+	}
+	cs.fixed[a], cs.fixed[b] = true, true
+	return nil
+}
+
+// rotate turns the blank around the square of the four cells given in
+// clockwise order, whichever way reaches the goal sooner.
+func (cs *constructor) rotate(square []int) bool {
+	s := cs.s
+	solved := func() bool {
+		for _, c := range square {
+			if s.tiles[c] != cs.goal[c] {
+				return false
+			}
+		}
+		return true
+	}
+	var best []Move
+	found := false
+	for _, dir := range []int{1, 3} {
+		var path []Move
+		for k := 0; k < 12 && !solved(); k++ {
+			at := 0
+			for square[at] != s.blank {
+				at++
+			}
+			next := square[(at+dir)%4]
+			for _, m := range allMoves {
+				if s.target(m) == next {
+					path = append(path, m)
+					s.slide(next)
+					break
+				}
+			}
+		}
+		if solved() && (!found || len(path) < len(best)) {
+			best, found = path, true
+		}
+		for k := len(path) - 1; k >= 0; k-- {
+			s.slide(s.target(path[k].Reverse()))
+		}
+	}
+	if !found {
+		return false
+	}
+	for _, m := range best {
+		cs.apply(m)
+	}
+	return true
+}
+
+// bfs searches the keys blank*cells+tile (or just the blank cell when tile
+// is -1) from the current board for one with done true, avoiding fixed
+// cells, and applies the blank moves leading there. It returns
+// errUnreachable if there is no such key.
+func (cs *constructor) bfs(tile int, done func(blank, at int) bool) error {
+	s := cs.s
+	cells := len(s.tiles)
+	if cs.seen == nil {
+		cs.parent = make([]int32, cells*cells)
+		cs.move = make([]Move, cells*cells)
+		cs.seen = make([]uint32, cells*cells)
+	}
+	cs.gen++
+	at := 0
+	if tile >= 0 {
+		at = s.pos[tile]
+	}
+	root := int32(s.blank*cells + at)
+	cs.seen[root] = cs.gen
+	cs.parent[root] = -1
+	cs.queue = append(cs.queue[:0], root)
+	for head := 0; head < len(cs.queue); head++ {
+		k := cs.queue[head]
+		blank, at := int(k)/cells, int(k)%cells
+		if done(blank, at) {
+			var path []Move
+			for ; cs.parent[k] >= 0; k = cs.parent[k] {
+				path = append(path, cs.move[k])
+			}
+			for i := len(path) - 1; i >= 0; i-- {
+				cs.apply(path[i])
+			}
+			return nil
+		}
+		for _, m := range allMoves {
+			next := s.neighbour(blank, m)
+			if next < 0 || cs.fixed[next] {
+				continue
+			}
+			nextAt := at
+			if tile >= 0 && next == at {
+				nextAt = blank
+			}
+			nk := int32(next*cells + nextAt)
+			if cs.seen[nk] == cs.gen {
+				continue
+			}
+			cs.seen[nk] = cs.gen
+			cs.parent[nk] = k
+			cs.move[nk] = m
+			cs.queue = append(cs.queue, nk)
+		}
+	}
+	return errUnreachable
+}
+
+// route slides tile to cell c.
+func (cs *constructor) route(tile, c int) error {
+	return cs.bfs(tile, func(_, at int) bool { return at == c })
+}
+
+// routeBlank moves the blank to cell c.
+func (cs *constructor) routeBlank(c int) error {
+	return cs.bfs(-1, func(blank, _ int) bool { return blank == c })
+}
@@ -0,0 +1,25 @@
+package npuzzle
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestConstruct(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 1000; trial++ {
+		rows, cols := 2+rng.Intn(6), 2+rng.Intn(6)
+		goal := SortedGoal(rows, cols, rng.Intn(rows*cols+1)-1)
+		if trial%3 == 0 {
+			goal = randomBoard(goal, rng)
+		}
+		start := randomBoard(goal, rng)
+		path, err := construct(start, goal)
+		if err != nil {
+			t.Fatalf("%v -> %v: %v", start, goal, err)
+		}
+		if reached, err := (&Answer{Moves: path}).Replay(start, goal); !reached || err != nil {
+			t.Fatalf("%v -> %v: %v does not reach the goal", start, goal, path)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package npuzzle
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+const (
+	// bfsCells is the largest board whose distance layers are enumerated by
+	// backward BFS (181440 boards on the 8-puzzle).
+	bfsCells = 9
+	// climbRestarts bounds the walks that get stuck before the goal distance.
+	climbRestarts = 50
+)
+
+// oracle measures optimal distances to one goal, reusing a single IDA*
+// searcher and its transposition table between queries.
+type oracle struct {
+	sr  *searcher
+	buf []uint16
+}
+
+func newOracle(goal Board, h heuristic) *oracle {
+	s := newState(goal)
+	s.setHeuristic(h)
+	return &oracle{
+		sr:  &searcher{s: s, goal: newState(goal), tt: newTranspositionTable(len(s.tiles), maxTableBits)},
+		buf: make([]uint16, len(s.tiles)),
+	}
+}
+
+func (o *oracle) distance(s *state) int {
+	s.save(o.buf)
+	o.sr.s.load(o.buf)
+	o.sr.path = o.sr.path[:0]
+	o.sr.run()
+	return len(o.sr.path)
+}
+
+// randomBoard returns a uniformly random board from which goal is reachable:
+// a random permutation is solvable with probability one half.
+func randomBoard(goal Board, rng *rand.Rand) Board {
+	rows, cols := len(goal), len(goal[0])
+	for {
+		perm := rng.Perm(rows * cols)
+		board := make(Board, rows)
+		for k := range board {
+			board[k] = perm[k*cols : (k+1)*cols]
+		}
+		if IsSolvable(board, goal) {
+			return board
+		}
+	}
+}
+
+// layer returns every board exactly d moves from goal, by breadth-first
+// search backwards from it.
+func layer(goal Board, d int) [][]uint16 {
+	s := newState(goal)
+	cells := len(s.tiles)
+	root := make([]uint16, cells)
+	s.save(root)
+	seen := map[uint64]bool{s.key: true}
+	cur := [][]uint16{root}
+	for k := 0; k < d && len(cur) > 0; k++ {
+		var next [][]uint16
+		for _, tiles := range cur {
+			s.load(tiles)
+			for _, m := range allMoves {
+				c := s.target(m)
+				if c < 0 {
+					continue
+				}
+				prev := s.blank
+				s.slide(c)
+				if !seen[s.key] {
+					seen[s.key] = true
+					b := make([]uint16, cells)
+					s.save(b)
+					next = append(next, b)
+				}
+				s.slide(prev)
+			}
+		}
+		cur = next
+	}
+	return cur
+}
+
+// climb walks away from goal, taking a random neighbour that the oracle finds
+// one move farther each step, until the board is d moves away. It gives up
+// when every neighbour is closer.
+func climb(goal Board, d int, o *oracle, rng *rand.Rand) (Board, bool) {
+	s := newState(goal)
+	for dist := 0; dist < d; dist++ {
+		moved := false
+		for _, k := range rng.Perm(len(allMoves)) {
+			c := s.target(allMoves[k])
+			if c < 0 {
+				continue
+			}
+			prev := s.blank
+			s.slide(c)
+			if o.distance(s) > dist {
+				moved = true
+				break
+			}
+			s.slide(prev)
+		}
+		if !moved {
+			return nil, false
+		}
+	}
+	return s.board(), true
+}
+
+// Generator produces random boards from which a goal is reachable.
+type Generator struct {
+	next func() (Board, error)
+}
+
+// NewGenerator returns a generator of boards uniformly drawn among those
+// that reach goal, or with distance >= 0 of boards whose optimal solution
+// has exactly distance moves. Boards of up to bfsCells cells are drawn
+// uniformly from the whole distance layer; larger ones come from random walks
+// that move away from goal one step at a time, measured by IDA* with the
+// named heuristic.
+func NewGenerator(goal Board, distance int, heuristicName string, rng *rand.Rand) (*Generator, error) {
+	switch {
+	case distance < 0:
+		return &Generator{next: func() (Board, error) { return randomBoard(goal, rng), nil }}, nil
+	case len(goal)*len(goal[0]) <= bfsCells:
+		boards := layer(goal, distance)
+		if len(boards) == 0 {
+			return nil, fmt.Errorf("no board is %d moves from the goal", distance)
+		}
+		s := newState(goal)
+		return &Generator{next: func() (Board, error) {
+			s.load(boards[rng.Intn(len(boards))])
+			return s.board(), nil
+		}}, nil
+	}
+	h, err := newHeuristic(context.Background(), heuristicName, goal, PDBOptions{})
+	if err != nil {
+		return nil, err
+	}
+	o := newOracle(goal, h)
+	return &Generator{next: func() (Board, error) {
+		for k := 0; k < climbRestarts; k++ {
+			if board, ok := climb(goal, distance, o, rng); ok {
+				return board, nil
+			}
+		}
+		return nil, fmt.Errorf("no walk reached %d moves from the goal in %d tries", distance, climbRestarts)
+	}}, nil
+}
+
+// Next returns the next board.
+func (g *Generator) Next() (Board, error) {
+	return g.next()
+}
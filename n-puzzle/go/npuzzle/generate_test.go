@@ -0,0 +1,37 @@
+package npuzzle
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGenerateDistance(t *testing.T) {
+	goal := Board{{1, 2, 3}, {4, 5, 6}, {7, 8, 0}}
+	// The 8-puzzle has exactly two boards 31 moves from the sorted goal.
+	if got := len(layer(goal, 31)); got != 2 {
+		t.Errorf("boards at distance 31: got %d, want 2", got)
+	}
+
+	o := newOracle(goal, newManhattan(goal))
+	rng := rand.New(rand.NewSource(1))
+	for _, d := range []int{0, 1, 10, 20} {
+		// As in NewGenerator, a climb that gets stuck is restarted.
+		var board Board
+		ok := false
+		for k := 0; k < climbRestarts && !ok; k++ {
+			board, ok = climb(goal, d, o, rng)
+		}
+		if !ok {
+			t.Errorf("climb(%d) got stuck %d times", d, climbRestarts)
+			continue
+		}
+		if got := o.distance(newState(board)); got != d {
+			t.Errorf("climb(%d) returned a board at distance %d", d, got)
+		}
+	}
+	for k := 0; k < 10; k++ {
+		if board := randomBoard(goal, rng); !IsSolvable(board, goal) {
+			t.Errorf("randomBoard returned unsolvable %v", board)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package npuzzle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"algorithms-solutions/n-puzzle/go/pdb"
+)
+
+// heuristic is an admissible estimate of the number of moves left to the
+// goal. The search keeps the estimate in the state and asks for an update
+// after every slide instead of re-evaluating the whole board.
+type heuristic interface {
+	// Estimate evaluates s from scratch and initialises any bookkeeping the
+	// heuristic keeps in s.aux.
+	Estimate(s *state) int
+	// Update returns the estimate after tile slid from cell from to cell to,
+	// given the estimate h before the move. s already reflects the move.
+	Update(s *state, h, tile, from, to int) int
+}
+
+// HeuristicNames lists the heuristics accepted by Options.Heuristic.
+var HeuristicNames = []string{"manhattan", "linear-conflict", "walking-distance", "pdb"}
+
+// PDBOptions configures the pattern database heuristic. Partition splits
+// the tiles into groups, e.g. "6-6-3" (default: groups of at most 6 tiles);
+// Dir caches the tables (default: the user cache directory).
+type PDBOptions struct {
+	Partition string
+	Dir       string
+}
+
+// newHeuristic returns the heuristic name towards goal. Only building
+// pattern databases takes long enough to be stopped by ctx.
+func newHeuristic(ctx context.Context, name string, goal Board, opts PDBOptions) (heuristic, error) {
+	switch name {
+	case "manhattan":
+		return newManhattan(goal), nil
+	case "linear-conflict":
+		return newLinearConflict(goal)
+	case "walking-distance":
+		return newWalkingDistance(goal)
+	case "pdb":
+		return newPatternDatabase(ctx, goal, opts)
+	}
+	return nil, fmt.Errorf("unknown heuristic %q (want one of %s)", name, strings.Join(HeuristicNames, ", "))
+}
+
+// goalCells returns the goal cell of every tile.
+func goalCells(goal Board) []int {
+	cols := len(goal[0])
+	cellOf := make([]int, len(goal)*cols)
+	for i, row := range goal {
+		for j, tile := range row {
+			cellOf[tile] = i*cols + j
+		}
+	}
+	return cellOf
+}
+
+// manhattan sums the grid distance of every tile to its goal cell.
+type manhattan struct {
+	dist [][]int
+}
+
+func newManhattan(goal Board) *manhattan {
+	cols := len(goal[0])
+	cellOf := goalCells(goal)
+	dist := make([][]int, len(cellOf))
+	dist[0] = make([]int, len(cellOf))
+	for tile := 1; tile < len(cellOf); tile++ {
+		dist[tile] = make([]int, len(cellOf))
+		for c := range dist[tile] {
+			dist[tile][c] = abs(c/cols-cellOf[tile]/cols) + abs(c%cols-cellOf[tile]%cols)
+		}
+	}
+	return &manhattan{dist: dist}
+}
+
+func (m *manhattan) Estimate(s *state) int {
+	h := 0
+	for c, tile := range s.tiles {
+		h += m.dist[tile][c]
+	}
+	return h
+}
+
+func (m *manhattan) Update(s *state, h, tile, from, to int) int {
+	return h + m.dist[tile][to] - m.dist[tile][from]
+}
+
+// linearConflict adds two moves to Manhattan distance for every tile that has
+// to leave its goal row (or column) so the others in that line can pass each
+// other. aux holds the conflicts of each row followed by each column.
+type linearConflict struct {
+	*manhattan
+	rows    int
+	cols    int
+	goalRow []int
+	goalCol []int
+}
+
+func newLinearConflict(goal Board) (*linearConflict, error) {
+	rows, cols := len(goal), len(goal[0])
+	if rows > 32 || cols > 32 {
+		return nil, fmt.Errorf("linear conflict supports boards up to 32x32, got %dx%d", rows, cols)
+	}
+	cellOf := goalCells(goal)
+	lc := &linearConflict{manhattan: newManhattan(goal), rows: rows, cols: cols, goalRow: make([]int, len(cellOf)), goalCol: make([]int, len(cellOf))}
+	for tile, c := range cellOf {
+		lc.goalRow[tile], lc.goalCol[tile] = c/cols, c%cols
+	}
+	return lc, nil
+}
+
+// conflicts returns how many tiles of line must leave it: the tiles already in
+// their goal line minus the longest run that is in goal order.
+func (lc *linearConflict) conflicts(s *state, line int, row bool) int {
+	var order [32]int
+	k, length := 0, lc.cols
+	if !row {
+		length = lc.rows
+	}
+	for x := 0; x < length; x++ {
+		c := line*lc.cols + x
+		if !row {
+			c = x*lc.cols + line
+		}
+		tile := s.tiles[c]
+		if tile == 0 {
+			continue
+		}
+		if row && lc.goalRow[tile] == line {
+			order[k] = lc.goalCol[tile]
+			k++
+		} else if !row && lc.goalCol[tile] == line {
+			order[k] = lc.goalRow[tile]
+			k++
+		}
+	}
+
+	var lis [32]int
+	best := 0
+	for i := 0; i < k; i++ {
+		lis[i] = 1
+		for j := 0; j < i; j++ {
+			if order[j] < order[i] && lis[j]+1 > lis[i] {
+				lis[i] = lis[j] + 1
+			}
+		}
+		if lis[i] > best {
+			best = lis[i]
+		}
+	}
+	return k - best
+}
+
+func (lc *linearConflict) Estimate(s *state) int {
+	if len(s.aux) != lc.rows+lc.cols {
+		s.aux = make([]int, lc.rows+lc.cols)
+	}
+	h := lc.manhattan.Estimate(s)
+	for line := 0; line < lc.rows; line++ {
+		s.aux[line] = lc.conflicts(s, line, true)
+		h += 2 * s.aux[line]
+	}
+	for line := 0; line < lc.cols; line++ {
+		s.aux[lc.rows+line] = lc.conflicts(s, line, false)
+		h += 2 * s.aux[lc.rows+line]
+	}
+	return h
+}
+
+// Update only revisits the two lines the tile crossed between: a vertical move
+// keeps the order of every column, a horizontal one the order of every row.
+func (lc *linearConflict) Update(s *state, h, tile, from, to int) int {
+	h = lc.manhattan.Update(s, h, tile, from, to)
+	a, b, row, off := from/lc.cols, to/lc.cols, true, 0
+	if a == b {
+		a, b, row, off = from%lc.cols, to%lc.cols, false, lc.rows
+	}
+	for _, line := range [2]int{a, b} {
+		c := lc.conflicts(s, line, row)
+		h += 2 * (c - s.aux[off+line])
+		s.aux[off+line] = c
+	}
+	return h
+}
+
+const wdBits = 3
+
+// wdTable maps a walking-distance pattern (how many tiles of each goal line
+// sit in each line, plus the blank's line) to the number of moves needed to
+// reach the goal pattern.
+type wdTable struct {
+	lines int
+	dist  map[uint64]uint8
+}
+
+func wdShift(lines, line, goalLine int) uint {
+	return uint(wdBits * (line*lines + goalLine))
+}
+
+func buildWalkingTable(lines int, goalKey uint64) *wdTable {
+	t := &wdTable{lines: lines, dist: map[uint64]uint8{goalKey: 0}}
+	blankShift := uint(wdBits * lines * lines)
+	mask := uint64(1)<<wdBits - 1
+
+	queue := []uint64{goalKey}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		d := t.dist[key]
+		b := int(key >> blankShift)
+		for _, nb := range [2]int{b - 1, b + 1} {
+			if nb < 0 || nb >= lines {
+				continue
+			}
+			for g := 0; g < lines; g++ {
+				if key>>wdShift(lines, nb, g)&mask == 0 {
+					continue
+				}
+				next := key - 1<<wdShift(lines, nb, g) + 1<<wdShift(lines, b, g)
+				next = next&(1<<blankShift-1) | uint64(nb)<<blankShift
+				if _, ok := t.dist[next]; !ok {
+					t.dist[next] = d + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	return t
+}
+
+// walkingDistance counts vertical moves on the row pattern and horizontal
+// moves on the column pattern; every move is one or the other, so the sum is
+// admissible. aux holds the row key, column key and their two distances.
+type walkingDistance struct {
+	rows     int
+	cols     int
+	goalRow  []int
+	goalCol  []int
+	rowTable *wdTable
+	colTable *wdTable
+}
+
+func newWalkingDistance(goal Board) (*walkingDistance, error) {
+	rows, cols := len(goal), len(goal[0])
+	if rows > 4 || cols > 4 {
+		return nil, fmt.Errorf("walking distance supports boards up to 4x4, got %dx%d", rows, cols)
+	}
+	cellOf := goalCells(goal)
+	wd := &walkingDistance{rows: rows, cols: cols, goalRow: make([]int, len(cellOf)), goalCol: make([]int, len(cellOf))}
+	for tile, c := range cellOf {
+		wd.goalRow[tile], wd.goalCol[tile] = c/cols, c%cols
+	}
+
+	goalState := newState(goal)
+	rowKey, colKey := wd.keys(goalState)
+	wd.rowTable = buildWalkingTable(rows, rowKey)
+	wd.colTable = buildWalkingTable(cols, colKey)
+	return wd, nil
+}
+
+func (wd *walkingDistance) keys(s *state) (uint64, uint64) {
+	var rowKey, colKey uint64
+	for c, tile := range s.tiles {
+		if tile == 0 {
+			continue
+		}
+		rowKey += 1 << wdShift(wd.rows, c/wd.cols, wd.goalRow[tile])
+		colKey += 1 << wdShift(wd.cols, c%wd.cols, wd.goalCol[tile])
+	}
+	rowKey |= uint64(s.blank/wd.cols) << uint(wdBits*wd.rows*wd.rows)
+	colKey |= uint64(s.blank%wd.cols) << uint(wdBits*wd.cols*wd.cols)
+	return rowKey, colKey
+}
+
+func (wd *walkingDistance) Estimate(s *state) int {
+	if len(s.aux) != 4 {
+		s.aux = make([]int, 4)
+	}
+	rowKey, colKey := wd.keys(s)
+	s.aux[0], s.aux[1] = int(rowKey), int(colKey)
+	s.aux[2], s.aux[3] = int(wd.rowTable.dist[rowKey]), int(wd.colTable.dist[colKey])
+	return s.aux[2] + s.aux[3]
+}
+
+func (wd *walkingDistance) Update(s *state, h, tile, from, to int) int {
+	if from/wd.cols != to/wd.cols {
+		blankShift := uint(wdBits * wd.rows * wd.rows)
+		key := uint64(s.aux[0])
+		key += 1<<wdShift(wd.rows, to/wd.cols, wd.goalRow[tile]) - 1<<wdShift(wd.rows, from/wd.cols, wd.goalRow[tile])
+		key = key&(1<<blankShift-1) | uint64(from/wd.cols)<<blankShift
+		s.aux[0], s.aux[2] = int(key), int(wd.rowTable.dist[key])
+	} else {
+		blankShift := uint(wdBits * wd.cols * wd.cols)
+		key := uint64(s.aux[1])
+		key += 1<<wdShift(wd.cols, to%wd.cols, wd.goalCol[tile]) - 1<<wdShift(wd.cols, from%wd.cols, wd.goalCol[tile])
+		key = key&(1<<blankShift-1) | uint64(from%wd.cols)<<blankShift
+		s.aux[1], s.aux[3] = int(key), int(wd.colTable.dist[key])
+	}
+	return s.aux[2] + s.aux[3]
+}
+
+// patternDatabase adds up disjoint pattern databases. aux holds the current
+// value of each table so a move only looks up the table of the moved tile.
+type patternDatabase struct {
+	tables []*pdb.Table
+	group  []int
+}
+
+// openTables holds every pattern database table opened or built by this
+// process, keyed by path, so solving many puzzles maps each file once.
+var openTables = struct {
+	sync.Mutex
+	m map[string]*pdb.Table
+}{m: make(map[string]*pdb.Table)}
+
+// loadTable returns the table of tiles at path, opening it, or building and
+// saving it if it is missing or stale, unless this process already has it.
+func loadTable(ctx context.Context, path string, rows, cols int, goal, tiles []int) (*pdb.Table, error) {
+	openTables.Lock()
+	defer openTables.Unlock()
+	if table, ok := openTables.m[path]; ok && table.Matches(rows, cols, goal, tiles) {
+		return table, nil
+	}
+	table, err := pdb.Open(path)
+	if err == nil && !table.Matches(rows, cols, goal, tiles) {
+		table.Close()
+		err = fmt.Errorf("%s was built for another board", path)
+	}
+	if err != nil {
+		table, err = pdb.Build(ctx, rows, cols, goal, tiles)
+		if err != nil {
+			return nil, err
+		}
+		if err := table.Save(path); err != nil {
+			return nil, err
+		}
+	}
+	openTables.m[path] = table
+	return table, nil
+}
+
+// newPatternDatabase opens the tables for the goal from opts.Dir (see the
+// pdbgen command), building and caching any that are missing or stale.
+func newPatternDatabase(ctx context.Context, goal Board, opts PDBOptions) (*patternDatabase, error) {
+	rows, cols := len(goal), len(goal[0])
+	flat := make([]int, 0, rows*cols)
+	for _, row := range goal {
+		flat = append(flat, row...)
+	}
+	groups, err := pdb.Partition(flat, opts.Partition)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = pdb.DefaultDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	pd := &patternDatabase{group: make([]int, rows*cols)}
+	for _, tiles := range groups {
+		path := filepath.Join(dir, pdb.FileName(rows, cols, flat, tiles))
+		table, err := loadTable(ctx, path, rows, cols, flat, tiles)
+		if err != nil {
+			return nil, err
+		}
+		for _, tile := range tiles {
+			pd.group[tile] = len(pd.tables)
+		}
+		pd.tables = append(pd.tables, table)
+	}
+	return pd, nil
+}
+
+func (pd *patternDatabase) Estimate(s *state) int {
+	if len(s.aux) != len(pd.tables) {
+		s.aux = make([]int, len(pd.tables))
+	}
+	h := 0
+	for k, table := range pd.tables {
+		s.aux[k] = table.Lookup(s.pos)
+		h += s.aux[k]
+	}
+	return h
+}
+
+func (pd *patternDatabase) Update(s *state, h, tile, from, to int) int {
+	k := pd.group[tile]
+	v := pd.tables[k].Lookup(s.pos)
+	h += v - s.aux[k]
+	s.aux[k] = v
+	return h
+}
@@ -0,0 +1,286 @@
+package npuzzle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"algorithms-solutions/n-puzzle/go/pdb"
+)
+
+// ParseError points at the line and column (both 1-based) of malformed
+// input. Col is 0 when the problem concerns the whole line, and Line is 0
+// when the problem is not tied to the input text.
+type ParseError struct {
+	Line, Col int
+	Msg       string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	if e.Col == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Col, e.Msg)
+}
+
+type token struct {
+	text string
+	col  int
+}
+
+// parser reads the input line by line, remembering the current line number
+// for error messages. A slash is a token of its own; rows queued by a compact
+// line are returned by next before the next line is read.
+type parser struct {
+	scanner *bufio.Scanner
+	line    int
+	queue   [][]token
+}
+
+// next returns the fields of the next line that is not blank, or false at the
+// end of the input.
+func (p *parser) next() ([]token, bool) {
+	if len(p.queue) > 0 {
+		tokens := p.queue[0]
+		p.queue = p.queue[1:]
+		return tokens, true
+	}
+	for p.scanner.Scan() {
+		p.line++
+		text := p.scanner.Text()
+		var tokens []token
+		for k := 0; k < len(text); {
+			switch {
+			case isSpace(text[k]):
+				k++
+			case text[k] == '/':
+				tokens = append(tokens, token{text: "/", col: k + 1})
+				k++
+			default:
+				start := k
+				for k < len(text) && !isSpace(text[k]) && text[k] != '/' {
+					k++
+				}
+				tokens = append(tokens, token{text: text[start:k], col: start + 1})
+			}
+		}
+		if len(tokens) > 0 {
+			return tokens, true
+		}
+	}
+	return nil, false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func (p *parser) errorf(col int, format string, args ...any) error {
+	return &ParseError{Line: p.line, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// number reads a line holding a single integer and returns it with its
+// column.
+func (p *parser) number(name string) (int, int, error) {
+	tokens, ok := p.next()
+	if !ok {
+		return 0, 0, &ParseError{Line: p.line + 1, Msg: fmt.Sprintf("unexpected end of input, expected %s", name)}
+	}
+	if len(tokens) > 1 {
+		return 0, 0, p.errorf(tokens[1].col, "unexpected %q after %s", tokens[1].text, name)
+	}
+	v, err := strconv.Atoi(tokens[0].text)
+	if err != nil {
+		return 0, 0, p.errorf(tokens[0].col, "%s must be an integer, got %q", name, tokens[0].text)
+	}
+	return v, tokens[0].col, nil
+}
+
+// board reads the rows of a board with cells cells. The board may be
+// rectangular: unless cols fixes the width, it is the length of the first row,
+// and the height follows from the cell count. Every tile 0..cells-1 must
+// occur once.
+func (p *parser) board(name string, cells, cols int) (Board, error) {
+	var board Board
+	type place struct{ line, col int }
+	seen := make(map[int]place, cells)
+	for rows := 1; len(board) < rows; {
+		tokens, ok := p.next()
+		if !ok && len(board) == 0 {
+			return nil, &ParseError{Line: p.line + 1, Msg: fmt.Sprintf("unexpected end of input, expected the %s", name)}
+		}
+		if !ok {
+			return nil, &ParseError{Line: p.line + 1, Msg: fmt.Sprintf("unexpected end of input, %s has %d of %d rows", name, len(board), rows)}
+		}
+		if cols == 0 {
+			if cells%len(tokens) != 0 {
+				return nil, p.errorf(0, "%s row has %d values, which does not divide %d cells into equal rows", name, len(tokens), cells)
+			}
+			cols = len(tokens)
+		}
+		if len(tokens) != cols {
+			return nil, p.errorf(0, "%s row has %d values, want %d", name, len(tokens), cols)
+		}
+		rows = cells / cols
+
+		row := make([]int, len(tokens))
+		for k, tok := range tokens {
+			tile, err := strconv.Atoi(tok.text)
+			if err != nil {
+				return nil, p.errorf(tok.col, "tile must be an integer, got %q", tok.text)
+			}
+			if tile < 0 || tile >= cells {
+				return nil, p.errorf(tok.col, "tile %d is outside 0..%d", tile, cells-1)
+			}
+			if first, dup := seen[tile]; dup {
+				return nil, p.errorf(tok.col, "tile %d already appears at line %d, column %d", tile, first.line, first.col)
+			}
+			seen[tile] = place{p.line, tok.col}
+			row[k] = tile
+		}
+		board = append(board, row)
+	}
+	return board, nil
+}
+
+// instance reads one puzzle: N, the blank index I and the start board, or
+// with explicitGoal N, the start board and the goal board.
+func (p *parser) instance(explicitGoal bool) (start, goal Board, err error) {
+	n, col, err := p.number("N")
+	if err != nil {
+		return nil, nil, err
+	}
+	if n < 1 {
+		return nil, nil, p.errorf(col, "N must be at least 1, got %d", n)
+	}
+
+	blank := -1
+	if !explicitGoal {
+		if blank, col, err = p.number("I"); err != nil {
+			return nil, nil, err
+		}
+		if blank < -1 || blank > n {
+			return nil, nil, p.errorf(col, "I must be -1 or within 0..%d, got %d", n, blank)
+		}
+	}
+
+	if start, err = p.board("start board", n+1, 0); err != nil {
+		return nil, nil, err
+	}
+	if explicitGoal {
+		goal, err = p.board("goal board", n+1, len(start[0]))
+	} else {
+		goal = SortedGoal(len(start), len(start[0]), blank)
+	}
+	return start, goal, err
+}
+
+// compact reads one puzzle from a single line holding the start board with
+// its rows separated by slashes ("8 6 7/2 5 4/3 0 1"). The goal is sorted
+// with the blank at index blank.
+func (p *parser) compact(blank int) (start, goal Board, err error) {
+	tokens, ok := p.next()
+	if !ok {
+		return nil, nil, &ParseError{Line: p.line + 1, Msg: "unexpected end of input, expected a board"}
+	}
+	cells, row := 0, 0
+	for k, tok := range tokens {
+		if tok.text != "/" {
+			cells++
+			continue
+		}
+		if k == row {
+			return nil, nil, p.errorf(tok.col, "empty board row")
+		}
+		p.queue = append(p.queue, tokens[row:k])
+		row = k + 1
+	}
+	if row == len(tokens) {
+		return nil, nil, p.errorf(tokens[len(tokens)-1].col, "empty board row")
+	}
+	p.queue = append(p.queue, tokens[row:])
+
+	if start, err = p.board("board", cells, 0); err != nil {
+		p.queue = nil
+		return nil, nil, err
+	}
+	if len(p.queue) > 0 {
+		err = p.errorf(p.queue[0][0].col, "board row after the last one")
+		p.queue = nil
+		return nil, nil, err
+	}
+	if blank < -1 || blank >= cells {
+		return nil, nil, p.errorf(0, "goal blank index %d is outside -1..%d", blank, cells-1)
+	}
+	return start, SortedGoal(len(start), len(start[0]), blank), nil
+}
+
+// SortedGoal is the goal board the solver builds from its blank index input.
+func SortedGoal(rows, cols, blank int) Board {
+	flat := pdb.Goal(rows*cols, blank)
+	goal := make(Board, rows)
+	for k := range goal {
+		goal[k] = flat[k*cols : (k+1)*cols]
+	}
+	return goal
+}
+
+// ParseInput reads the solver input: N, the blank index I (-1 for last) and
+// the start board, one row per line, or with explicitGoal N, the start board
+// and the goal board. The board width is the length of the first row.
+func ParseInput(r io.Reader, explicitGoal bool) (start, goal Board, err error) {
+	p := &parser{scanner: bufio.NewScanner(r)}
+	if start, goal, err = p.instance(explicitGoal); err != nil {
+		return nil, nil, err
+	}
+	if tokens, ok := p.next(); ok {
+		return nil, nil, p.errorf(tokens[0].col, "unexpected %q after the last board row", tokens[0].text)
+	}
+	if err := p.scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return start, goal, nil
+}
+
+// Instance is one puzzle of a batch file, starting on line Line.
+type Instance struct {
+	Line        int
+	Start, Goal Board
+}
+
+// ReadInstances reads the puzzles of a batch file. A file whose first line
+// holds a single number is a sequence of solver inputs, one per block;
+// otherwise every line is a compact board ("8 6 7/2 5 4/3 0 1") solved
+// towards the sorted goal with the blank at index blank.
+func ReadInstances(r io.Reader, explicitGoal bool, blank int) ([]Instance, error) {
+	p := &parser{scanner: bufio.NewScanner(r)}
+	compact := false
+	var instances []Instance
+	for first := true; ; first = false {
+		tokens, ok := p.next()
+		if !ok {
+			break
+		}
+		if first {
+			compact = len(tokens) > 1
+		}
+		p.queue = append([][]token{tokens}, p.queue...)
+
+		inst := Instance{Line: p.line}
+		var err error
+		if compact {
+			inst.Start, inst.Goal, err = p.compact(blank)
+		} else {
+			inst.Start, inst.Goal, err = p.instance(explicitGoal)
+		}
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst)
+	}
+	return instances, p.scanner.Err()
+}
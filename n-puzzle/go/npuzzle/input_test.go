@@ -0,0 +1,157 @@
+package npuzzle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInputErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		goal  bool
+		want  string
+	}{
+		{"empty", "", false, "line 1: unexpected end of input, expected N"},
+		{"blank lines only", "\n\n", false, "line 3: unexpected end of input, expected N"},
+		{"N not a number", "eight\n-1\n", false, "line 1, column 1: N must be an integer, got \"eight\""},
+		{"N indented", "  x\n", false, "line 1, column 3: N must be an integer, got \"x\""},
+		{"N too small", "0\n-1\n0\n", false, "line 1, column 1: N must be at least 1, got 0"},
+		{"extra header value", "8 3\n-1\n", false, "line 1, column 3: unexpected \"3\" after N"},
+		{"missing I", "8\n", false, "line 2: unexpected end of input, expected I"},
+		{"I not a number", "8\nlast\n", false, "line 2, column 1: I must be an integer, got \"last\""},
+		{"I too large", "8\n9\n", false, "line 2, column 1: I must be -1 or within 0..8, got 9"},
+		{"I too small", "8\n-2\n", false, "line 2, column 1: I must be -1 or within 0..8, got -2"},
+		{"no board", "8\n-1\n", false, "line 3: unexpected end of input, expected the start board"},
+		{"short board", "8\n-1\n1 2 3\n4 5 6\n", false, "line 5: unexpected end of input, start board has 2 of 3 rows"},
+		{"width does not divide", "8\n-1\n1 2 3 4\n", false, "line 3: start board row has 4 values, which does not divide 9 cells into equal rows"},
+		{"ragged row", "8\n-1\n1 2 3\n4 5\n6 7 8 0\n", false, "line 4: start board row has 2 values, want 3"},
+		{"tile not a number", "8\n-1\n1 2 3\n4 x 6\n7 8 0\n", false, "line 4, column 3: tile must be an integer, got \"x\""},
+		{"tile out of range", "8\n-1\n1 2 3\n4 5 6\n7 9 0\n", false, "line 5, column 3: tile 9 is outside 0..8"},
+		{"negative tile", "8\n-1\n1 2 3\n4 -5 6\n7 8 0\n", false, "line 4, column 3: tile -5 is outside 0..8"},
+		{"duplicate tile", "8\n-1\n1 2 3\n4 5 6\n7 1 0\n", false, "line 5, column 3: tile 1 already appears at line 3, column 1"},
+		{"trailing data", "8\n-1\n1 2 3\n4 5 6\n7 8 0\n9\n", false, "line 6, column 1: unexpected \"9\" after the last board row"},
+		{"goal missing", "8\n1 2 3\n4 5 6\n7 8 0\n", true, "line 5: unexpected end of input, expected the goal board"},
+		{"goal of other width", "5\n1 2 3\n4 5 0\n\n1 2\n3 4\n5 0\n", true, "line 5: goal board row has 2 values, want 3"},
+		{"goal duplicate", "3\n1 2\n3 0\n1 1\n3 0\n", true, "line 4, column 3: tile 1 already appears at line 4, column 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseInput(strings.NewReader(tt.input), tt.goal)
+			if err == nil {
+				t.Fatalf("ParseInput succeeded, want error %q", tt.want)
+			}
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("error %v is not a ParseError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		goal      bool
+		wantStart Board
+		wantGoal  Board
+	}{
+		{
+			name:      "square",
+			input:     "8\n-1\n1 2 3\n4 5 6\n7 8 0\n",
+			wantStart: Board{{1, 2, 3}, {4, 5, 6}, {7, 8, 0}},
+			wantGoal:  Board{{1, 2, 3}, {4, 5, 6}, {7, 8, 0}},
+		},
+		{
+			name:      "blank index",
+			input:     "8\n4\n1 2 3\n4 0 5\n6 7 8\n",
+			wantStart: Board{{1, 2, 3}, {4, 0, 5}, {6, 7, 8}},
+			wantGoal:  Board{{1, 2, 3}, {4, 0, 6}, {7, 8, 5}},
+		},
+		{
+			name:      "rectangular with CRLF and blank lines",
+			input:     "\r\n7\r\n0\r\n\r\n 5 6 7 0 \r\n1 2 3 4\r\n\r\n",
+			wantStart: Board{{5, 6, 7, 0}, {1, 2, 3, 4}},
+			wantGoal:  Board{{0, 2, 3, 4}, {5, 6, 7, 1}},
+		},
+		{
+			name:      "explicit goal",
+			input:     "5\n1 2\n3 4\n5 0\n\n0 5\n4 3\n2 1\n",
+			goal:      true,
+			wantStart: Board{{1, 2}, {3, 4}, {5, 0}},
+			wantGoal:  Board{{0, 5}, {4, 3}, {2, 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, goal, err := ParseInput(strings.NewReader(tt.input), tt.goal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(start, tt.wantStart) {
+				t.Errorf("start = %v, want %v", start, tt.wantStart)
+			}
+			if !reflect.DeepEqual(goal, tt.wantGoal) {
+				t.Errorf("goal = %v, want %v", goal, tt.wantGoal)
+			}
+		})
+	}
+}
+
+func TestReadInstances(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		blank  int
+		starts []Board
+		lines  []int
+		err    string
+	}{
+		{
+			name:   "blocks",
+			blank:  -1,
+			input:  "3\n-1\n1 2\n3 0\n\n3\n0\n0 1\n2 3\n",
+			starts: []Board{{{1, 2}, {3, 0}}, {{0, 1}, {2, 3}}},
+			lines:  []int{1, 6},
+		},
+		{
+			name:   "compact",
+			blank:  -1,
+			input:  "8 6 7/2 5 4/3 0 1\n\n1 2 3 0/4 5 6 7\n",
+			starts: []Board{{{8, 6, 7}, {2, 5, 4}, {3, 0, 1}}, {{1, 2, 3, 0}, {4, 5, 6, 7}}},
+			lines:  []int{1, 3},
+		},
+		{name: "compact empty row", input: "1 2//3 0\n", blank: -1, err: "line 1, column 5: empty board row"},
+		{name: "compact trailing slash", input: "1 2/3 0/\n", blank: -1, err: "line 1, column 8: empty board row"},
+		{name: "compact blank index", input: "1 2/3 0\n", blank: 4, err: "line 1: goal blank index 4 is outside -1..3"},
+		{name: "block error", input: "3\n-1\n1 2\n3 0\n3\n-1\n1 2\n3 3\n", blank: -1, err: "line 8, column 3: tile 3 already appears at line 8, column 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := ReadInstances(strings.NewReader(tt.input), false, tt.blank)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(instances) != len(tt.starts) {
+				t.Fatalf("got %d instances, want %d", len(instances), len(tt.starts))
+			}
+			for k, inst := range instances {
+				if !reflect.DeepEqual(inst.Start, tt.starts[k]) || inst.Line != tt.lines[k] {
+					t.Errorf("instance %d = %v at line %d, want %v at line %d", k, inst.Start, inst.Line, tt.starts[k], tt.lines[k])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package npuzzle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Errors wrapped by BudgetError when a search runs out of its budget.
+var (
+	ErrNodeBudget   = errors.New("node budget exhausted")
+	ErrMemoryBudget = errors.New("memory budget exhausted")
+)
+
+// searchBudget bounds one solve; zero fields are unlimited. maxBytes covers
+// the search's own tables and lists as counted in Stats.Memory.
+type searchBudget struct {
+	maxNodes int64
+	maxBytes int64
+}
+
+// BudgetError reports a search stopped by its context or budget. Err is the
+// context's error, ErrNodeBudget or ErrMemoryBudget. No solution is shorter
+// than LowerBound.
+type BudgetError struct {
+	Err        error
+	LowerBound int
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%v, no solution shorter than %d moves", e.Err, e.LowerBound)
+}
+
+func (e *BudgetError) Unwrap() error {
+	return e.Err
+}
+
+// searchLimits is the running form of a budget. Strategies charge it with
+// the nodes they generate (in batches, as often as every 1024 nodes) and the
+// memory they hold, and stop once it reports exhaustion. It is safe for
+// concurrent use; a nil *searchLimits never stops a search.
+type searchLimits struct {
+	ctx    context.Context
+	budget searchBudget
+	nodes  atomic.Int64
+
+	mu  sync.Mutex
+	err error
+}
+
+func newSearchLimits(ctx context.Context, budget searchBudget) *searchLimits {
+	return &searchLimits{ctx: ctx, budget: budget}
+}
+
+// charge adds nodes to the generated count, records that the search holds
+// bytes bytes, and reports whether it has to stop.
+func (l *searchLimits) charge(nodes, bytes int64) bool {
+	if l == nil {
+		return false
+	}
+	total := l.nodes.Add(nodes)
+	var err error
+	switch {
+	case l.budget.maxNodes > 0 && total >= l.budget.maxNodes:
+		err = ErrNodeBudget
+	case l.budget.maxBytes > 0 && bytes > l.budget.maxBytes:
+		err = ErrMemoryBudget
+	case l.ctx != nil:
+		err = l.ctx.Err()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = err
+	}
+	return l.err != nil
+}
+
+// Err returns why the search was stopped, or nil.
+func (l *searchLimits) Err() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// tableBits caps maxBits so that tables transposition tables fit in the
+// memory budget together.
+func (l *searchLimits) tableBits(maxBits uint, tables int) uint {
+	if l == nil || l.budget.maxBytes <= 0 {
+		return maxBits
+	}
+	for maxBits > 10 && int64(tables)<<maxBits*ttEntryBytes > l.budget.maxBytes {
+		maxBits--
+	}
+	return maxBits
+}
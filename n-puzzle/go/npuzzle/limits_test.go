@@ -0,0 +1,90 @@
+package npuzzle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSolveBudget(t *testing.T) {
+	goal := SortedGoal(4, 4, -1)
+	start := toBoard(benchSuite[0].tiles, 4)
+	h := newManhattan(goal)
+	minBound := h.Estimate(newState(start))
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Nodes are charged in batches of about 1024 per searcher, which
+	// bounds how far a search may run past its node budget.
+	const batch = 1024
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		budget   searchBudget
+		strategy strategy
+		err      error
+	}{
+		{"nodes ida", context.Background(), searchBudget{maxNodes: 50000}, idaStar{}, ErrNodeBudget},
+		{"nodes parallel", context.Background(), searchBudget{maxNodes: 50000}, parallelIDAStar{workers: 2}, ErrNodeBudget},
+		{"nodes astar", context.Background(), searchBudget{maxNodes: 50000}, bestFirst{weight: 1}, ErrNodeBudget},
+		{"memory astar", context.Background(), searchBudget{maxBytes: 1 << 20}, bestFirst{weight: 1}, ErrMemoryBudget},
+		{"memory bidirectional", context.Background(), searchBudget{maxBytes: 1 << 20}, bidirectional{back: newManhattan(start)}, ErrMemoryBudget},
+		{"canceled", canceled, searchBudget{}, idaStar{}, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sol, err := search(tt.ctx, start, goal, h, tt.strategy, tt.budget)
+			if tt.budget.maxNodes > 0 && sol.Stats.Generated > tt.budget.maxNodes+2*batch {
+				t.Errorf("generated %d nodes, budget %d", sol.Stats.Generated, tt.budget.maxNodes)
+			}
+			var b *BudgetError
+			if !errors.As(err, &b) {
+				t.Fatalf("search returned %v, want a BudgetError", err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if b.LowerBound < minBound || b.LowerBound > benchSuite[0].optimal {
+				t.Errorf("lower bound %d outside [h(start), optimal] = [%d, %d]", b.LowerBound, minBound, benchSuite[0].optimal)
+			}
+		})
+	}
+}
+
+// TestSolveCanceledBuild checks that ctx also stops building pattern
+// databases, which Solve reports like a stopped search.
+func TestSolveCanceledBuild(t *testing.T) {
+	goal := SortedGoal(4, 4, -1)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := Options{Heuristic: "pdb", PDB: PDBOptions{Dir: t.TempDir()}}
+	_, err := Solve(canceled, toBoard(benchSuite[0].tiles, 4), goal, opts)
+	var b *BudgetError
+	if !errors.As(err, &b) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Solve returned %v, want a BudgetError for context.Canceled", err)
+	}
+}
+
+// TestSolveTimeout checks that a search past its deadline stops within a
+// small multiple of it instead of finishing the current IDA* iteration.
+func TestSolveTimeout(t *testing.T) {
+	// Korf's first instance, whose goal has the blank first, takes
+	// hundreds of millions of nodes with the Manhattan distance.
+	goal := Board{{0, 1, 2, 3}, {4, 5, 6, 7}, {8, 9, 10, 11}, {12, 13, 14, 15}}
+	start := toBoard([]int{14, 13, 15, 7, 11, 12, 9, 5, 6, 0, 2, 1, 4, 8, 10, 3}, 4)
+	const deadline = 100 * time.Millisecond
+	for _, strategy := range []string{"ida", "parallel-ida"} {
+		ctx, cancel := context.WithTimeout(context.Background(), deadline)
+		began := time.Now()
+		_, err := Solve(ctx, start, goal, Options{Strategy: strategy, Workers: 2})
+		elapsed := time.Since(began)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s: err = %v, want context.DeadlineExceeded", strategy, err)
+		}
+		if elapsed > 5*deadline {
+			t.Errorf("%s: stopped after %v, deadline %v", strategy, elapsed, deadline)
+		}
+	}
+}
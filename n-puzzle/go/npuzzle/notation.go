@@ -0,0 +1,66 @@
+package npuzzle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style is how the moves of a solution are written.
+type Style int
+
+const (
+	// Words writes one direction per line ("left").
+	Words Style = iota
+	// Letters writes all directions on one line as U, D, L and R ("LURD").
+	Letters
+	// Numbers writes the numbers of the slid tiles on one line ("8 5 2").
+	Numbers
+)
+
+// Notation is a convention for writing solutions. The zero value is the
+// solver's own: one word per move naming the direction the tile slides.
+type Notation struct {
+	Style Style
+	// Blank names the direction the blank moves instead, the opposite of
+	// the tile's. Numbers do not depend on it.
+	Blank bool
+}
+
+// Format returns the lines that follow the move count when path, applied
+// to start, is written in notation n. A path with no moves has no lines.
+func (n Notation) Format(start Board, path []Move) ([]string, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	s := newState(start)
+	words := make([]string, len(path))
+	letters := make([]byte, len(path))
+	for k, m := range path {
+		c := s.target(m)
+		if c < 0 {
+			return nil, fmt.Errorf("move %d (%s) has no tile to slide", k+1, m)
+		}
+		if n.Style == Numbers {
+			words[k] = strconv.Itoa(s.tiles[c])
+		}
+		s.slide(c)
+
+		if !n.Blank {
+			m = m.Reverse()
+		}
+		switch n.Style {
+		case Words:
+			words[k] = m.String()
+		case Letters:
+			letters[k] = m.Letter()
+		}
+	}
+	switch n.Style {
+	case Letters:
+		return []string{string(letters)}, nil
+	case Numbers:
+		return []string{strings.Join(words, " ")}, nil
+	}
+	return words, nil
+}
@@ -0,0 +1,68 @@
+package npuzzle
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNotationFormat(t *testing.T) {
+	start := Board{{1, 2, 3}, {4, 0, 6}, {7, 5, 8}}
+	path := []Move{Down, Right}
+	tests := []struct {
+		notation Notation
+		want     []string
+	}{
+		{Notation{}, []string{"up", "left"}},
+		{Notation{Blank: true}, []string{"down", "right"}},
+		{Notation{Style: Letters}, []string{"UL"}},
+		{Notation{Style: Letters, Blank: true}, []string{"DR"}},
+		{Notation{Style: Numbers}, []string{"5 8"}},
+		{Notation{Style: Numbers, Blank: true}, []string{"5 8"}},
+	}
+	for _, tt := range tests {
+		got, err := tt.notation.Format(start, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: got %q, want %q", tt.notation, got, tt.want)
+		}
+	}
+	if _, err := (Notation{}).Format(start, []Move{Up, Up, Up}); err == nil {
+		t.Error("move off the board: no error")
+	}
+}
+
+// TestNotationRoundTrip checks that ParseAnswer reads back every notation
+// Format writes.
+func TestNotationRoundTrip(t *testing.T) {
+	start := Board{{8, 6, 7}, {2, 5, 4}, {3, 0, 1}}
+	goal := SortedGoal(3, 3, -1)
+	solution, err := Solve(context.Background(), start, goal, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, style := range []Style{Words, Letters, Numbers} {
+		for _, blank := range []bool{false, true} {
+			n := Notation{Style: style, Blank: blank}
+			lines, err := n.Format(start, solution.Moves)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := strconv.Itoa(len(solution.Moves)) + "\n" + strings.Join(lines, "\n") + "\n"
+			a, err := ParseAnswer(strings.NewReader(text), n)
+			if err != nil {
+				t.Fatalf("%+v: %v", n, err)
+			}
+			if reached, err := a.Replay(start, goal); !reached || err != nil {
+				t.Fatalf("%+v: replay reached = %v, err = %v", n, reached, err)
+			}
+			if !reflect.DeepEqual(a.Moves, solution.Moves) {
+				t.Errorf("%+v: read back %v, want %v", n, a.Moves, solution.Moves)
+			}
+		}
+	}
+}
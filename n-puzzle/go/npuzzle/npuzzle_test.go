@@ -0,0 +1,99 @@
+package npuzzle
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// benchSuite holds fixed 4x4 instances (blank last in the goal) with their
+// optimal lengths, chosen so one pass takes well under a second.
+var benchSuite = []struct {
+	tiles   []int
+	optimal int
+}{
+	{[]int{14, 5, 4, 10, 6, 3, 13, 0, 1, 2, 7, 8, 15, 12, 9, 11}, 44},
+	{[]int{3, 11, 5, 8, 9, 0, 15, 2, 10, 4, 14, 13, 1, 7, 12, 6}, 46},
+	{[]int{11, 7, 8, 14, 6, 0, 3, 12, 9, 1, 4, 13, 10, 2, 15, 5}, 46},
+	{[]int{11, 1, 0, 12, 4, 5, 6, 2, 9, 13, 15, 7, 8, 14, 3, 10}, 48},
+	{[]int{8, 11, 14, 15, 10, 0, 13, 2, 3, 6, 4, 12, 1, 7, 5, 9}, 56},
+}
+
+func toBoard(tiles []int, cols int) Board {
+	board := make(Board, len(tiles)/cols)
+	for i := range board {
+		board[i] = tiles[i*cols : (i+1)*cols]
+	}
+	return board
+}
+
+// BenchmarkIDAStar reports node throughput of the IDA* hot path over the
+// fixed 4x4 suite.
+func BenchmarkIDAStar(b *testing.B) {
+	goal := SortedGoal(4, 4, -1)
+	h := newManhattan(goal)
+	var nodes int64
+	var elapsed time.Duration
+
+	for k := 0; k < b.N; k++ {
+		for _, inst := range benchSuite {
+			start := newState(toBoard(inst.tiles, 4))
+			start.setHeuristic(h)
+			began := time.Now()
+			path, found, stats := idaStar{}.Search(start, newState(goal), nil)
+			if !found || len(path) != inst.optimal {
+				b.Fatalf("%v: got %d moves, want %d", inst.tiles, len(path), inst.optimal)
+			}
+			elapsed += time.Since(began)
+			nodes += stats.Generated
+		}
+	}
+
+	b.ReportMetric(float64(nodes)/elapsed.Seconds(), "nodes/s")
+	b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+}
+
+// BenchmarkParallelIDAStar solves the same suite with parallel IDA* on all
+// CPUs; compare ns/op with BenchmarkIDAStar for the speed-up.
+func BenchmarkParallelIDAStar(b *testing.B) {
+	goal := SortedGoal(4, 4, -1)
+	h := newManhattan(goal)
+	strategy := parallelIDAStar{workers: runtime.NumCPU()}
+
+	for k := 0; k < b.N; k++ {
+		for _, inst := range benchSuite {
+			start := newState(toBoard(inst.tiles, 4))
+			start.setHeuristic(h)
+			path, found, _ := strategy.Search(start, newState(goal), nil)
+			if !found || len(path) != inst.optimal {
+				b.Fatalf("%v: got %d moves, want %d", inst.tiles, len(path), inst.optimal)
+			}
+		}
+	}
+}
+
+// BenchmarkSlide times one move and its undo with the incremental update of
+// each heuristic, the innermost step of every search.
+func BenchmarkSlide(b *testing.B) {
+	goal := SortedGoal(4, 4, -1)
+	for _, name := range []string{"manhattan", "linear-conflict", "walking-distance"} {
+		b.Run(name, func(b *testing.B) {
+			h, err := newHeuristic(context.Background(), name, goal, PDBOptions{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			s := newState(toBoard(benchSuite[0].tiles, 4))
+			s.setHeuristic(h)
+			b.ResetTimer()
+			for k := 0; k < b.N; k++ {
+				m := allMoves[k&3]
+				if c := s.target(m); c >= 0 {
+					prev := s.blank
+					s.slide(c)
+					s.slide(prev)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package npuzzle
+
+import (
+	"context"
+	"time"
+	"unsafe"
+)
+
+type pathKey struct {
+	key uint64
+	g   int
+}
+
+// pathCounter counts and lists the solutions of a known optimal length.
+// Every prefix of a shortest solution is itself shortest, so the number of
+// ways to finish from a board depends only on the board and its depth, never
+// on the moves that led there. Counts are memoized on both: boards reached
+// again through another move order (transpositions) are searched once, yet
+// every sequence through them is counted.
+type pathCounter struct {
+	s      *state
+	goal   *state
+	length int
+	path   []Move
+	memo   map[pathKey]uint64
+	stats  Stats
+	limits *searchLimits
+}
+
+// bytes estimates the memory held by the memo, counting map entries at twice
+// their size like frontier.bytes.
+func (pc *pathCounter) bytes() int64 {
+	return int64(len(pc.memo)) * 2 * int64(unsafe.Sizeof(pathKey{})+unsafe.Sizeof(uint64(0)))
+}
+
+// count returns the number of move sequences that take the current board,
+// reached after g moves, to the goal in exactly pc.length moves in total.
+// Undoing the last move never lies on a shortest path, so it is skipped.
+func (pc *pathCounter) count(g int, reverseMove Move) uint64 {
+	pc.stats.Generated++
+	if pc.stats.Generated&1023 == 0 && pc.limits.charge(1024, pc.bytes()) {
+		return 0
+	}
+	s := pc.s
+	if g+s.h > pc.length {
+		return 0
+	}
+	if g == pc.length {
+		if s.equals(pc.goal) {
+			return 1
+		}
+		return 0
+	}
+	k := pathKey{key: s.key, g: g}
+	if n, ok := pc.memo[k]; ok {
+		return n
+	}
+
+	pc.stats.Expanded++
+	var n uint64
+	for _, m := range allMoves {
+		if m == reverseMove {
+			continue
+		}
+		c := s.target(m)
+		if c < 0 {
+			continue
+		}
+		prev := s.blank
+		s.slide(c)
+		n += pc.count(g+1, m.Reverse())
+		s.slide(prev)
+	}
+	pc.memo[k] = n
+	return n
+}
+
+// each calls visit with every shortest solution, in move order, until visit
+// returns false. Only moves with a non-zero count are followed, so no dead
+// end is entered. visit must not keep the slice.
+func (pc *pathCounter) each(visit func(path []Move) bool) {
+	pc.path = pc.path[:0]
+	pc.walk(0, noMove, visit)
+}
+
+func (pc *pathCounter) walk(g int, reverseMove Move, visit func(path []Move) bool) bool {
+	if g == pc.length {
+		return visit(pc.path)
+	}
+	s := pc.s
+	for _, m := range allMoves {
+		if m == reverseMove {
+			continue
+		}
+		c := s.target(m)
+		if c < 0 {
+			continue
+		}
+		prev := s.blank
+		s.slide(c)
+		more := true
+		if pc.count(g+1, m.Reverse()) > 0 {
+			pc.path = append(pc.path, m)
+			more = pc.walk(g+1, m.Reverse(), visit)
+			pc.path = pc.path[:len(pc.path)-1]
+		}
+		s.slide(prev)
+		if !more {
+			return false
+		}
+	}
+	return true
+}
+
+// SolutionSet holds every shortest solution of a puzzle: Count sequences of
+// Length moves.
+type SolutionSet struct {
+	Length int
+	Count  uint64
+	Stats  Stats
+	pc     *pathCounter
+}
+
+// Each calls visit with every solution, in move order, until visit returns
+// false. visit must not keep the slice.
+func (ss *SolutionSet) Each(visit func(moves []Move) bool) {
+	if ss.pc == nil {
+		return
+	}
+	ss.pc.each(visit)
+}
+
+// CountSolutions finds the optimal length with IDA* (opts.Strategy is
+// ignored) and then counts every solution of that length. Errors are those
+// of Solve, and the Stats of the returned set are filled in either way.
+func CountSolutions(ctx context.Context, initial, goal Board, opts Options) (*SolutionSet, error) {
+	if err := checkBoards(initial, goal); err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+	opts.Strategy = "ida"
+	h, st, err := opts.build(ctx, initial, goal)
+	if err != nil {
+		return &SolutionSet{Stats: Stats{Elapsed: time.Since(startTime)}}, err
+	}
+
+	if !IsSolvable(initial, goal) {
+		return &SolutionSet{Stats: Stats{Elapsed: time.Since(startTime)}}, ErrUnsolvable
+	}
+
+	start := newState(initial)
+	start.setHeuristic(h)
+	lim := newSearchLimits(ctx, opts.budget())
+	path, found, stats := st.Search(start, newState(goal), lim)
+	if !found {
+		stats.Elapsed = time.Since(startTime)
+		if err := lim.Err(); err != nil {
+			return &SolutionSet{Stats: stats}, &BudgetError{Err: err, LowerBound: stats.LowerBound}
+		}
+		return &SolutionSet{Stats: stats}, ErrUnsolvable
+	}
+
+	// The search leaves start on the goal.
+	start = newState(initial)
+	start.setHeuristic(h)
+	pc := &pathCounter{
+		s:      start,
+		goal:   newState(goal),
+		length: len(path),
+		memo:   make(map[pathKey]uint64),
+		limits: lim,
+	}
+	total := pc.count(0, noMove)
+	stats.Expanded += pc.stats.Expanded
+	stats.Generated += pc.stats.Generated
+	stats.Memory = max(stats.Memory, pc.bytes())
+	stats.Elapsed = time.Since(startTime)
+	if err := lim.Err(); err != nil {
+		return &SolutionSet{Stats: stats}, &BudgetError{Err: err, LowerBound: pc.length}
+	}
+	// Every count the listing needs is memoized now, so the budget that
+	// bounded the counting must not cut solutions out of the listing.
+	pc.limits = nil
+	return &SolutionSet{Length: pc.length, Count: total, Stats: stats, pc: pc}, nil
+}
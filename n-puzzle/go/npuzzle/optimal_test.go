@@ -0,0 +1,76 @@
+package npuzzle
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestCountSolutions(t *testing.T) {
+	// Lengths and counts from a breadth-first search counting shortest paths.
+	tests := []struct {
+		tiles         []int
+		cols          int
+		length, count int
+	}{
+		{[]int{1, 2, 3, 4, 5, 6, 0, 7, 8}, 3, 2, 1},
+		{[]int{8, 6, 7, 2, 5, 4, 3, 0, 1}, 3, 31, 40},
+		{[]int{6, 4, 7, 8, 5, 0, 3, 2, 1}, 3, 31, 40},
+		{[]int{1, 2, 3, 0, 4, 5, 6, 7}, 4, 0, 1},
+	}
+	for _, tt := range tests {
+		start := toBoard(tt.tiles, tt.cols)
+		goal := SortedGoal(len(start), tt.cols, -1)
+		if tt.length == 0 {
+			start = goal
+		}
+		set, err := CountSolutions(context.Background(), start, goal, Options{})
+		if err != nil {
+			t.Fatalf("%v: %v", tt.tiles, err)
+		}
+		if set.Length != tt.length || set.Count != uint64(tt.count) {
+			t.Errorf("%v: got %d solutions of %d moves, want %d of %d", tt.tiles, set.Count, set.Length, tt.count, tt.length)
+		}
+
+		seen := map[string]bool{}
+		set.Each(func(path []Move) bool {
+			a := &Answer{Moves: path}
+			if reached, err := a.Replay(start, goal); !reached || err != nil {
+				t.Errorf("%v: %v does not reach the goal", tt.tiles, path)
+			}
+			seen[fmt.Sprint(path)] = true
+			return true
+		})
+		if len(seen) != tt.count {
+			t.Errorf("%v: listed %d distinct solutions, want %d", tt.tiles, len(seen), tt.count)
+		}
+	}
+}
+
+// TestCountSolutionsBudget checks that a node budget either stops the
+// counting with an error or, if the counting fits in it, drops no solution
+// from the listing that follows. The budgets around the unbounded node
+// count include some the listing alone would run past.
+func TestCountSolutionsBudget(t *testing.T) {
+	start := toBoard([]int{8, 6, 7, 2, 5, 4, 3, 0, 1}, 3)
+	goal := SortedGoal(3, 3, -1)
+	set, err := CountSolutions(context.Background(), start, goal, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := set.Stats.Generated
+	for budget := generated - 2048; budget < generated+2048; budget += 128 {
+		set, err := CountSolutions(context.Background(), start, goal, Options{MaxNodes: budget})
+		if err != nil {
+			continue
+		}
+		listed := 0
+		set.Each(func([]Move) bool {
+			listed++
+			return true
+		})
+		if uint64(listed) != set.Count {
+			t.Errorf("budget %d: listed %d of %d solutions", budget, listed, set.Count)
+		}
+	}
+}
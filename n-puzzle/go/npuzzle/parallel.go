@@ -0,0 +1,211 @@
+package npuzzle
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	// unitsPerWorker is how many work units each worker should get per
+	// iteration, so a few deep subtrees do not leave the others idle.
+	unitsPerWorker = 32
+	maxSplitDepth  = 16
+	// workerTableBits keeps the per-worker transposition tables small enough
+	// to give one to every worker.
+	workerTableBits = 20
+)
+
+// parallelIDAStar runs each IDA* iteration over a pool of goroutines. The tree
+// is cut at a shallow depth into work units that the workers take from a
+// shared queue; the smallest f above the threshold is combined atomically,
+// and the first worker to reach the goal stops the others. Every threshold
+// below the current one was exhausted, so any path found is optimal.
+type parallelIDAStar struct {
+	workers int
+	trace   *Tracer
+}
+
+type workUnit struct {
+	path []Move
+}
+
+func (p parallelIDAStar) Search(start, goal *state, lim *searchLimits) ([]Move, bool, Stats) {
+	var stats Stats
+	workers := max(p.workers, 1)
+
+	root := make([]uint16, len(start.tiles))
+	start.save(root)
+
+	searchers := make([]*searcher, workers)
+	for w := range searchers {
+		searchers[w] = &searcher{
+			s:      start.clone(),
+			goal:   goal,
+			tt:     newTranspositionTable(len(start.tiles), lim.tableBits(workerTableBits, workers)),
+			trace:  p.trace,
+			limits: lim,
+		}
+	}
+
+	limit := start.h
+	for {
+		stats.Iterations++
+		p.trace.begin(stats.Iterations, limit)
+		expanded, generated := stats.Expanded, stats.Generated
+		units, next, path, ok := p.split(start, goal, limit, workers*unitsPerWorker, &stats)
+		lim.charge(stats.Generated-generated, 0)
+		if ok {
+			p.trace.end(stats.Expanded-expanded, stats.Generated-generated)
+			stats.LowerBound = len(path)
+			return path, true, stats
+		}
+
+		var stop atomic.Bool
+		var nextLimit atomic.Int64
+		nextLimit.Store(int64(next))
+		var mu sync.Mutex
+		var found []Move
+
+		queue := make(chan workUnit, len(units))
+		for _, u := range units {
+			queue <- u
+		}
+		close(queue)
+
+		var wg sync.WaitGroup
+		for _, sr := range searchers {
+			wg.Add(1)
+			go func(sr *searcher) {
+				defer wg.Done()
+				sr.limit = limit
+				sr.stop = &stop
+				sr.stopped = false
+				sr.tt.reset()
+				if path := sr.work(queue, root, &nextLimit); path != nil {
+					mu.Lock()
+					if found == nil {
+						found = path
+					}
+					mu.Unlock()
+				}
+			}(sr)
+		}
+		wg.Wait()
+
+		memory := int64(cap(units)) * int64(unsafe.Sizeof(workUnit{}))
+		for _, u := range units {
+			memory += int64(cap(u.path)) * int64(unsafe.Sizeof(Move(0)))
+		}
+		for _, sr := range searchers {
+			// poll charges whole batches; the rest of this iteration is
+			// charged here so the budget sees every node.
+			lim.charge(sr.stats.Generated&1023, 0)
+			stats.Generated += sr.stats.Generated
+			stats.Expanded += sr.stats.Expanded
+			stats.MaxFrontier = max(stats.MaxFrontier, sr.stats.MaxFrontier)
+			memory += sr.tt.bytes() + int64(cap(sr.path))*int64(unsafe.Sizeof(Move(0)))
+			sr.stats = Stats{}
+		}
+		stats.Memory = max(stats.Memory, memory)
+		p.trace.end(stats.Expanded-expanded, stats.Generated-generated)
+		if found != nil {
+			stats.LowerBound = limit
+			return found, true, stats
+		}
+		if lim.Err() != nil {
+			stats.LowerBound = limit
+			return nil, false, stats
+		}
+		if nextLimit.Load() == math.MaxInt {
+			return nil, false, stats
+		}
+		limit = int(nextLimit.Load())
+	}
+}
+
+// work searches the units of queue under sr.limit, lowering nextLimit to
+// the smallest f pruned, until the queue is empty or sr.stop is set. If it
+// reaches the goal it sets sr.stop and returns the path; the units still
+// queued are left for no one.
+func (sr *searcher) work(queue <-chan workUnit, root []uint16, nextLimit *atomic.Int64) []Move {
+	for !sr.stop.Load() {
+		u, ok := <-queue
+		if !ok {
+			break
+		}
+		sr.s.load(root)
+		for _, m := range u.path {
+			sr.s.slide(sr.s.target(m))
+		}
+		sr.path = append(sr.path[:0], u.path...)
+
+		f, ok := sr.dfsIterative(len(u.path))
+		if ok {
+			sr.stop.Store(true)
+			return append([]Move(nil), sr.path...)
+		}
+		for cur := nextLimit.Load(); int64(f) < cur; cur = nextLimit.Load() {
+			if nextLimit.CompareAndSwap(cur, int64(f)) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// split expands start breadth-first under limit until a level holds at least
+// target distinct boards (or maxSplitDepth is reached) and returns them as
+// work units together with the smallest f pruned on the way. If the goal lies
+// above that level its path is returned instead.
+func (p parallelIDAStar) split(start, goal *state, limit, target int, stats *Stats) ([]workUnit, int, []Move, bool) {
+	s := start.clone()
+	next := math.MaxInt
+	level := []workUnit{{}}
+	stats.Generated++
+
+	for depth := 0; depth < maxSplitDepth && len(level) < target; depth++ {
+		seen := make(map[uint64]bool)
+		var deeper []workUnit
+		for _, u := range level {
+			for _, m := range u.path {
+				s.slide(s.target(m))
+			}
+			if s.equals(goal) {
+				return nil, next, u.path, true
+			}
+
+			stats.Expanded++
+			for _, m := range allMoves {
+				if len(u.path) > 0 && m == u.path[len(u.path)-1].Reverse() {
+					continue
+				}
+				c := s.target(m)
+				if c < 0 {
+					continue
+				}
+				prev := s.blank
+				s.slide(c)
+				stats.Generated++
+				if f := depth + 1 + s.h; f > limit {
+					next = min(next, f)
+				} else if !seen[s.key] {
+					seen[s.key] = true
+					path := append(append(make([]Move, 0, depth+1), u.path...), m)
+					deeper = append(deeper, workUnit{path: path})
+				}
+				s.slide(prev)
+			}
+
+			for k := len(u.path) - 1; k >= 0; k-- {
+				s.slide(s.target(u.path[k].Reverse()))
+			}
+		}
+		if len(deeper) == 0 {
+			return nil, next, nil, false
+		}
+		level = deeper
+	}
+	return level, next, nil, false
+}
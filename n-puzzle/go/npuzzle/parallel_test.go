@@ -0,0 +1,67 @@
+package npuzzle
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// stopAfter sets stop once the board has changed n times, standing in for
+// another worker that reaches the goal.
+type stopAfter struct {
+	heuristic
+	n    int
+	stop *atomic.Bool
+}
+
+func (h *stopAfter) Update(s *state, est, tile, from, to int) int {
+	if h.n--; h.n == 0 {
+		h.stop.Store(true)
+	}
+	return h.heuristic.Update(s, est, tile, from, to)
+}
+
+// TestParallelStop checks that a worker leaves the queue once the goal is
+// found and drops its current unit as soon as another worker finds it.
+func TestParallelStop(t *testing.T) {
+	goal := SortedGoal(4, 4, -1)
+	inst := benchSuite[0]
+	newWorker := func(h heuristic, limit int, stop *atomic.Bool) (*searcher, []uint16) {
+		start := newState(toBoard(inst.tiles, 4))
+		start.setHeuristic(h)
+		root := make([]uint16, len(start.tiles))
+		start.save(root)
+		return &searcher{s: start, goal: newState(goal), tt: newTranspositionTable(16, 16), limit: limit, stop: stop}, root
+	}
+
+	var stop atomic.Bool
+	sr, root := newWorker(newManhattan(goal), inst.optimal, &stop)
+	var stats Stats
+	units, _, _, _ := parallelIDAStar{}.split(sr.s, sr.goal, inst.optimal, 64, &stats)
+	queue := make(chan workUnit, len(units))
+	for _, u := range units {
+		queue <- u
+	}
+	close(queue)
+	var next atomic.Int64
+	if path := sr.work(queue, root, &next); len(path) != inst.optimal || !stop.Load() {
+		t.Fatalf("work returned %d moves, stop = %v; want %d moves and stop set", len(path), stop.Load(), inst.optimal)
+	}
+	if len(queue) == 0 {
+		t.Errorf("the queue of %d units was drained after the goal was found", len(units))
+	}
+
+	// The whole tree below a threshold short of the goal is far larger
+	// than the nodes searched before the stop.
+	var other atomic.Bool
+	sr, root = newWorker(&stopAfter{heuristic: newManhattan(goal), n: 100, stop: &other}, inst.optimal-2, &other)
+	queue = make(chan workUnit, 2)
+	queue <- workUnit{}
+	queue <- workUnit{}
+	close(queue)
+	if path := sr.work(queue, root, &next); path != nil {
+		t.Fatalf("work returned %v below the optimal length", path)
+	}
+	if sr.stats.Generated > 100 || len(queue) != 1 {
+		t.Errorf("generated %d nodes and left %d units after the stop, want at most 100 and 1", sr.stats.Generated, len(queue))
+	}
+}
@@ -0,0 +1,352 @@
+package npuzzle
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"sync/atomic"
+	"unsafe"
+)
+
+// state is a flat board that is changed in place by slide. key identifies the
+// board: when every cell fits in 64 bits it is the exact packed board (4 bits
+// per cell on a 4x4), otherwise it is a Zobrist hash of the tiles. h is the
+// heuristic estimate, kept up to date by slide; aux is bookkeeping owned by
+// the heuristic.
+type state struct {
+	rows  int
+	cols  int
+	tiles []int
+	pos   []int
+	blank int
+	key   uint64
+	exact bool
+	keys  [][]uint64
+	heur  heuristic
+	h     int
+	aux   []int
+}
+
+func newState(board Board) *state {
+	rows, cols := len(board), len(board[0])
+	cells := rows * cols
+	bitsPerCell := bits.Len(uint(cells - 1))
+	exact := bitsPerCell*cells <= 64
+
+	keys := make([][]uint64, cells)
+	rng := rand.New(rand.NewSource(int64(cells)))
+	for tile := 1; tile < cells; tile++ {
+		keys[tile] = make([]uint64, cells)
+		for c := 0; c < cells; c++ {
+			if exact {
+				keys[tile][c] = uint64(tile) << (bitsPerCell * c)
+			} else {
+				keys[tile][c] = rng.Uint64()
+			}
+		}
+	}
+	keys[0] = make([]uint64, cells)
+
+	s := &state{rows: rows, cols: cols, tiles: make([]int, cells), pos: make([]int, cells), exact: exact, keys: keys}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			c := i*cols + j
+			s.tiles[c] = board[i][j]
+			s.pos[board[i][j]] = c
+			s.key ^= keys[board[i][j]][c]
+			if board[i][j] == 0 {
+				s.blank = c
+			}
+		}
+	}
+	return s
+}
+
+// setHeuristic evaluates the board with h, which slide then keeps current.
+func (s *state) setHeuristic(h heuristic) {
+	s.heur = h
+	s.h = h.Estimate(s)
+}
+
+// target returns the cell the blank reaches with move m, or -1 if it would
+// leave the board.
+func (s *state) target(m Move) int {
+	return s.neighbour(s.blank, m)
+}
+
+// neighbour returns the cell next to c in the direction of m, or -1 at the
+// edge of the board.
+func (s *state) neighbour(c int, m Move) int {
+	i, j := c/s.cols+moves[m].di, c%s.cols+moves[m].dj
+	if i < 0 || i >= s.rows || j < 0 || j >= s.cols {
+		return -1
+	}
+	return i*s.cols + j
+}
+
+// slide moves the tile at cell c into the blank and updates key and h by the
+// moved tile only. Sliding back to the previous blank cell undoes the move.
+func (s *state) slide(c int) {
+	tile, to := s.tiles[c], s.blank
+	s.key ^= s.keys[tile][c] ^ s.keys[tile][to]
+	s.tiles[to] = tile
+	s.tiles[c] = 0
+	s.pos[tile] = to
+	s.pos[0] = c
+	s.blank = c
+	if s.heur != nil {
+		s.h = s.heur.Update(s, s.h, tile, c, to)
+	}
+}
+
+// load overwrites the board with tiles (row-major, as written by save) and
+// re-evaluates key and h.
+func (s *state) load(tiles []uint16) {
+	s.key = 0
+	for c, t := range tiles {
+		tile := int(t)
+		s.tiles[c] = tile
+		s.pos[tile] = c
+		s.key ^= s.keys[tile][c]
+		if tile == 0 {
+			s.blank = c
+		}
+	}
+	if s.heur != nil {
+		s.h = s.heur.Estimate(s)
+	}
+}
+
+// clone returns an independent copy of s sharing the read-only tables.
+func (s *state) clone() *state {
+	c := *s
+	c.tiles = append([]int(nil), s.tiles...)
+	c.pos = append([]int(nil), s.pos...)
+	c.aux = append([]int(nil), s.aux...)
+	return &c
+}
+
+func (s *state) save(dst []uint16) {
+	for c, tile := range s.tiles {
+		dst[c] = uint16(tile)
+	}
+}
+
+func (s *state) board() Board {
+	board := make(Board, s.rows)
+	for i := range board {
+		board[i] = append([]int(nil), s.tiles[i*s.cols:(i+1)*s.cols]...)
+	}
+	return board
+}
+
+func (s *state) equals(other *state) bool {
+	if s.key != other.key {
+		return false
+	}
+	if s.exact {
+		return true
+	}
+	for c, tile := range s.tiles {
+		if other.tiles[c] != tile {
+			return false
+		}
+	}
+	return true
+}
+
+const maxTableBits = 22
+
+type ttEntry struct {
+	key uint64
+	g   int32
+	gen uint32
+}
+
+// transpositionTable is a fixed-size, always-replace table of the smallest g
+// each board was reached with during the current IDA* iteration.
+type transpositionTable struct {
+	entries []ttEntry
+	shift   uint
+	gen     uint32
+}
+
+// newTranspositionTable sizes the table for a board of cells cells, capped
+// at 2^maxBits entries so memory stays bounded on large boards.
+func newTranspositionTable(cells int, maxBits uint) *transpositionTable {
+	tableBits := uint(10)
+	states := 1.0
+	for k := 2; k <= cells && tableBits < maxBits; k++ {
+		states *= float64(k)
+		for tableBits < maxBits && float64(uint64(1)<<tableBits) < states {
+			tableBits++
+		}
+	}
+	return &transpositionTable{
+		entries: make([]ttEntry, 1<<tableBits),
+		shift:   64 - tableBits,
+	}
+}
+
+const ttEntryBytes = int64(unsafe.Sizeof(ttEntry{}))
+
+// bytes is the memory held by the table.
+func (t *transpositionTable) bytes() int64 {
+	return int64(len(t.entries)) * ttEntryBytes
+}
+
+// reset forgets every entry without touching the table memory.
+func (t *transpositionTable) reset() {
+	t.gen++
+}
+
+// visit reports whether key was already reached with a cost of at most g in
+// this iteration, recording g otherwise.
+func (t *transpositionTable) visit(key uint64, g int) bool {
+	e := &t.entries[(key*0x9E3779B97F4A7C15)>>t.shift]
+	if e.gen == t.gen && e.key == key && int(e.g) <= g {
+		return true
+	}
+	*e = ttEntry{key: key, g: int32(g), gen: t.gen}
+	return false
+}
+
+// searcher runs IDA* iterations from s. Once poll reports that the search
+// has to stop, or another worker sets stop, stopped is set and every node
+// returns at once, so the whole recursion unwinds instead of only the node
+// where the stop was seen.
+type searcher struct {
+	s       *state
+	goal    *state
+	tt      *transpositionTable
+	path    []Move
+	limit   int
+	stats   Stats
+	stop    *atomic.Bool
+	stopped bool
+	trace   *Tracer
+	limits  *searchLimits
+}
+
+// dfsIterative runs one IDA* iteration below the current limit. It returns
+// true when the goal is reached, otherwise the smallest f that exceeded the
+// limit (math.MaxInt if none did).
+func (sr *searcher) dfsIterative(g int) (int, bool) {
+	if sr.stopped || sr.stop != nil && sr.stop.Load() {
+		sr.stopped = true
+		return math.MaxInt, false
+	}
+	sr.stats.Generated++
+	if sr.stats.Generated&1023 == 0 && sr.poll() {
+		sr.stopped = true
+		if sr.stop != nil {
+			sr.stop.Store(true)
+		}
+		return math.MaxInt, false
+	}
+	s := sr.s
+	f := g + s.h
+	if f > sr.limit {
+		return f, false
+	}
+
+	if s.equals(sr.goal) {
+		return f, true
+	}
+
+	if sr.tt.visit(s.key, g) {
+		return math.MaxInt, false
+	}
+
+	sr.stats.Expanded++
+	if len(sr.path) >= sr.stats.MaxFrontier {
+		sr.stats.MaxFrontier = len(sr.path) + 1
+	}
+
+	reverseMove := noMove
+	if len(sr.path) > 0 {
+		reverseMove = sr.path[len(sr.path)-1].Reverse()
+	}
+
+	minF := math.MaxInt
+	for _, m := range allMoves {
+		if m == reverseMove {
+			continue
+		}
+		c := s.target(m)
+		if c < 0 {
+			continue
+		}
+
+		prev := s.blank
+		s.slide(c)
+		sr.path = append(sr.path, m)
+
+		next, found := sr.dfsIterative(g + 1)
+		if found {
+			return next, true
+		}
+
+		sr.path = sr.path[:len(sr.path)-1]
+		s.slide(prev)
+		if sr.stopped {
+			return math.MaxInt, false
+		}
+
+		if next < minF {
+			minF = next
+		}
+	}
+
+	return minF, false
+}
+
+// poll runs every 1024 generated nodes. It feeds the Tracer and reports
+// whether the limits stopped the search.
+func (sr *searcher) poll() bool {
+	sr.trace.tick()
+	return sr.limits.charge(1024, 0)
+}
+
+// run raises the limit until an iteration reaches the goal. It returns false
+// if the goal cannot be reached at any cost or the limits stopped the search;
+// in the latter case no solution is shorter than sr.limit.
+func (sr *searcher) run() bool {
+	sr.limit = sr.s.h
+	sr.stopped = false
+	for {
+		sr.stats.Iterations++
+		sr.tt.reset()
+		sr.trace.begin(sr.stats.Iterations, sr.limit)
+		expanded, generated := sr.stats.Expanded, sr.stats.Generated
+		next, found := sr.dfsIterative(0)
+		sr.trace.end(sr.stats.Expanded-expanded, sr.stats.Generated-generated)
+		if found {
+			return true
+		}
+		if sr.stopped || next == math.MaxInt {
+			return false
+		}
+		sr.limit = next
+	}
+}
+
+// idaStar is iterative deepening A*: memory stays linear in the solution
+// depth, at the price of re-expanding the shallow levels on every iteration.
+type idaStar struct {
+	trace *Tracer
+}
+
+func (ida idaStar) Search(start, goal *state, lim *searchLimits) ([]Move, bool, Stats) {
+	sr := &searcher{
+		s:      start,
+		goal:   goal,
+		tt:     newTranspositionTable(len(start.tiles), lim.tableBits(maxTableBits, 1)),
+		trace:  ida.trace,
+		limits: lim,
+	}
+	found := sr.run()
+	sr.stats.Memory = sr.tt.bytes() + int64(cap(sr.path))*int64(unsafe.Sizeof(Move(0)))
+	sr.stats.LowerBound = sr.limit
+	return sr.path, found, sr.stats
+}
@@ -0,0 +1,169 @@
+package npuzzle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// ErrUnsolvable is returned when the goal cannot be reached from the start.
+var ErrUnsolvable = errors.New("goal is unreachable")
+
+// Options selects how a puzzle is solved. The zero value solves with IDA*
+// and the Manhattan heuristic, without limits.
+type Options struct {
+	// Heuristic is one of HeuristicNames ("manhattan" if empty).
+	Heuristic string
+	// PDB configures the "pdb" heuristic.
+	PDB PDBOptions
+	// Strategy is one of StrategyNames ("ida" if empty).
+	Strategy string
+	// Epsilon bounds "wastar" to (1+Epsilon) times the optimal length.
+	Epsilon float64
+	// Workers is the goroutine count of "parallel-ida" (all CPUs if 0).
+	Workers int
+	// Trace, if set, receives the thresholds and progress of IDA*.
+	Trace *Tracer
+	// MaxNodes and MaxMemory (in bytes) stop the search with a BudgetError
+	// once it has generated that many nodes or holds that much memory in its
+	// tables and lists. Zero is unlimited.
+	MaxNodes  int64
+	MaxMemory int64
+}
+
+func (opts Options) budget() searchBudget {
+	return searchBudget{maxNodes: opts.MaxNodes, maxBytes: opts.MaxMemory}
+}
+
+// build returns the heuristic towards goal and the strategy for solving start.
+// If ctx ends while tables are built, the error is a *BudgetError.
+func (opts Options) build(ctx context.Context, start, goal Board) (heuristic, strategy, error) {
+	name := opts.Heuristic
+	if name == "" {
+		name = "manhattan"
+	}
+	strategyName := opts.Strategy
+	if strategyName == "" {
+		strategyName = "ida"
+	}
+	if name == "pdb" && strategyName == "bidirectional" {
+		// The backward side would need tables for the start board, built
+		// anew for every puzzle.
+		return nil, nil, errors.New("the pdb heuristic cannot be used with the bidirectional strategy")
+	}
+	h, err := newHeuristic(ctx, name, goal, opts.PDB)
+	if err != nil {
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			err = &BudgetError{Err: err}
+		}
+		return nil, nil, err
+	}
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	st, err := newStrategy(strategyName, strategyOptions{epsilon: opts.Epsilon, workers: workers, trace: opts.Trace}, func() (heuristic, error) {
+		return newHeuristic(ctx, name, start, opts.PDB)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, st, nil
+}
+
+// Stats is reported by every strategy so runs can be compared:
+// Generated counts created nodes (including the start), Expanded those whose
+// successors were generated, and MaxFrontier the peak open-list size (for
+// IDA* the deepest path). Iterations is the number of IDA* thresholds and
+// Memory the bytes held by the search's tables and lists at their largest.
+// LowerBound is the length no solution can be shorter than, as far as the
+// search got.
+type Stats struct {
+	Expanded    int64
+	Generated   int64
+	MaxFrontier int
+	Iterations  int
+	Memory      int64
+	LowerBound  int
+	Elapsed     time.Duration
+}
+
+// Solution is a sequence of blank moves from the start to the goal, with
+// the statistics of the search that found it.
+type Solution struct {
+	Moves []Move
+	Stats Stats
+}
+
+// checkBoards reports boards that are not a permutation of 0..cells-1 of
+// one shape. Boards read by ParseInput always pass.
+func checkBoards(start, goal Board) error {
+	if len(start) == 0 || len(start[0]) == 0 {
+		return errors.New("empty start board")
+	}
+	rows, cols := len(start), len(start[0])
+	for _, b := range []struct {
+		name  string
+		board Board
+	}{{"start", start}, {"goal", goal}} {
+		if len(b.board) != rows {
+			return fmt.Errorf("%s board has %d rows, want %d", b.name, len(b.board), rows)
+		}
+		seen := make([]bool, rows*cols)
+		for i, row := range b.board {
+			if len(row) != cols {
+				return fmt.Errorf("%s board row %d has %d tiles, want %d", b.name, i+1, len(row), cols)
+			}
+			for _, tile := range row {
+				if tile < 0 || tile >= rows*cols || seen[tile] {
+					return fmt.Errorf("%s board is not a permutation of 0..%d", b.name, rows*cols-1)
+				}
+				seen[tile] = true
+			}
+		}
+	}
+	return nil
+}
+
+// Solve searches for a sequence of blank moves that turns start into goal.
+// It returns ErrUnsolvable if there is none and a *BudgetError if ctx or
+// the limits in opts stopped the search first; the Stats of the returned
+// Solution are filled in either way. ctx and Stats.Elapsed also cover
+// building the heuristic's tables.
+func Solve(ctx context.Context, start, goal Board, opts Options) (Solution, error) {
+	began := time.Now()
+	if err := checkBoards(start, goal); err != nil {
+		return Solution{}, err
+	}
+	h, st, err := opts.build(ctx, start, goal)
+	if err != nil {
+		return Solution{Stats: Stats{Elapsed: time.Since(began)}}, err
+	}
+	sol, err := search(ctx, start, goal, h, st, opts.budget())
+	sol.Stats.Elapsed = time.Since(began)
+	return sol, err
+}
+
+func search(ctx context.Context, initial, goal Board, h heuristic, st strategy, budget searchBudget) (Solution, error) {
+	startTime := time.Now()
+
+	if !IsSolvable(initial, goal) {
+		return Solution{Stats: Stats{Elapsed: time.Since(startTime)}}, ErrUnsolvable
+	}
+
+	start := newState(initial)
+	start.setHeuristic(h)
+
+	lim := newSearchLimits(ctx, budget)
+	path, found, stats := st.Search(start, newState(goal), lim)
+	stats.Elapsed = time.Since(startTime)
+	if !found {
+		if err := lim.Err(); err != nil {
+			return Solution{Stats: stats}, &BudgetError{Err: err, LowerBound: stats.LowerBound}
+		}
+		return Solution{Stats: stats}, ErrUnsolvable
+	}
+	return Solution{Moves: path, Stats: stats}, nil
+}
@@ -0,0 +1,38 @@
+package npuzzle
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	goal := SortedGoal(3, 3, -1)
+	start := Board{{8, 6, 7}, {2, 5, 4}, {3, 0, 1}}
+	for _, name := range StrategyNames {
+		solution, err := Solve(context.Background(), start, goal, Options{Strategy: name, Epsilon: 0.5})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if reached, err := (&Answer{Moves: solution.Moves}).Replay(start, goal); !reached || err != nil {
+			t.Errorf("%s: %v does not reach the goal", name, solution.Moves)
+		}
+		if name != "wastar" && len(solution.Moves) != 31 {
+			t.Errorf("%s: %d moves, want 31", name, len(solution.Moves))
+		}
+	}
+
+	unsolvable := Board{{1, 2, 3}, {4, 5, 6}, {8, 7, 0}}
+	if _, err := Solve(context.Background(), unsolvable, goal, Options{}); !errors.Is(err, ErrUnsolvable) {
+		t.Errorf("unsolvable board: err = %v, want ErrUnsolvable", err)
+	}
+	if _, err := Solve(context.Background(), Board{{1, 2}, {3, 0}}, goal, Options{}); err == nil {
+		t.Error("boards of different sizes: no error")
+	}
+	if _, err := Solve(context.Background(), Board{{1, 1, 3}, {4, 5, 6}, {7, 8, 0}}, goal, Options{}); err == nil {
+		t.Error("repeated tile: no error")
+	}
+	if _, err := Solve(context.Background(), start, goal, Options{Heuristic: "pdb", Strategy: "bidirectional"}); err == nil {
+		t.Error("pdb with bidirectional: no error")
+	}
+}
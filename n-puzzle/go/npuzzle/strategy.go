@@ -0,0 +1,349 @@
+package npuzzle
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// strategy searches for a sequence of blank moves (indices into moves) that
+// turns start into goal. start carries the heuristic; goal has none. The
+// search gives up, returning false, once lim reports exhaustion.
+type strategy interface {
+	Search(start, goal *state, lim *searchLimits) ([]Move, bool, Stats)
+}
+
+// StrategyNames lists the strategies accepted by Options.Strategy.
+var StrategyNames = []string{"ida", "parallel-ida", "astar", "wastar", "bidirectional"}
+
+type strategyOptions struct {
+	// epsilon bounds weighted A* to (1+epsilon) times the optimal length.
+	epsilon float64
+	// workers is the goroutine count of parallel IDA*.
+	workers int
+	// trace, if set, receives the thresholds and progress of IDA*.
+	trace *Tracer
+}
+
+// newStrategy builds the named strategy. back returns a heuristic towards the
+// start board and is only called for bidirectional search.
+func newStrategy(name string, opts strategyOptions, back func() (heuristic, error)) (strategy, error) {
+	switch name {
+	case "ida":
+		return idaStar{trace: opts.trace}, nil
+	case "parallel-ida":
+		if opts.workers < 1 {
+			return nil, fmt.Errorf("workers must be positive, got %d", opts.workers)
+		}
+		return parallelIDAStar{workers: opts.workers, trace: opts.trace}, nil
+	case "astar":
+		return bestFirst{weight: 1}, nil
+	case "wastar":
+		if opts.epsilon < 0 {
+			return nil, fmt.Errorf("epsilon must not be negative, got %g", opts.epsilon)
+		}
+		return bestFirst{weight: 1 + opts.epsilon}, nil
+	case "bidirectional":
+		h, err := back()
+		if err != nil {
+			return nil, err
+		}
+		return bidirectional{back: h}, nil
+	}
+	return nil, fmt.Errorf("unknown strategy %q (want one of %s)", name, strings.Join(StrategyNames, ", "))
+}
+
+type openItem struct {
+	prio float64
+	g    int32
+	id   int32
+}
+
+// priorityQueue is a binary min-heap on prio, preferring deeper nodes on ties.
+type priorityQueue []openItem
+
+func (pq priorityQueue) Len() int {
+	return len(pq)
+}
+
+func (pq priorityQueue) Less(i, j int) bool {
+	if pq[i].prio != pq[j].prio {
+		return pq[i].prio < pq[j].prio
+	}
+	return pq[i].g > pq[j].g
+}
+
+func (pq priorityQueue) Swap(i, j int) {
+	pq[i], pq[j] = pq[j], pq[i]
+}
+
+func (pq *priorityQueue) Push(x any) {
+	*pq = append(*pq, x.(openItem))
+}
+
+func (pq *priorityQueue) Pop() any {
+	old := *pq
+	item := old[len(old)-1]
+	*pq = old[:len(old)-1]
+	return item
+}
+
+type node struct {
+	key    uint64
+	g      int32
+	parent int32
+	move   Move
+	closed bool
+}
+
+// frontier is the open and closed list of one search direction. Boards are
+// stored back to back in arena; seen maps a board key to its cheapest node,
+// so entries left in the heap for a board that was later improved are skipped
+// when popped.
+type frontier struct {
+	s     *state
+	cells int
+	arena []uint16
+	nodes []node
+	seen  map[uint64]int32
+	open  priorityQueue
+}
+
+func newFrontier(s *state) *frontier {
+	return &frontier{s: s, cells: len(s.tiles), seen: make(map[uint64]int32)}
+}
+
+// add records the current board of fr.s, reached with cost g from parent by
+// move, and queues it with priority prio.
+func (fr *frontier) add(g int, parent int32, move Move, prio float64) int32 {
+	id := int32(len(fr.nodes))
+	fr.nodes = append(fr.nodes, node{key: fr.s.key, g: int32(g), parent: parent, move: move})
+	fr.arena = append(fr.arena, make([]uint16, fr.cells)...)
+	fr.s.save(fr.arena[len(fr.arena)-fr.cells:])
+	fr.seen[fr.s.key] = id
+	heap.Push(&fr.open, openItem{prio: prio, g: int32(g), id: id})
+	return id
+}
+
+// improves reports whether reaching the current board of fr.s with cost g
+// beats every earlier visit. Closed boards are only reopened when reopen is
+// set.
+func (fr *frontier) improves(g int, reopen bool) bool {
+	id, ok := fr.seen[fr.s.key]
+	if !ok {
+		return true
+	}
+	nd := fr.nodes[id]
+	return int(nd.g) > g && (reopen || !nd.closed)
+}
+
+// top drops stale heap entries and returns the best live one.
+func (fr *frontier) top() (openItem, bool) {
+	for fr.open.Len() > 0 {
+		it := fr.open[0]
+		nd := &fr.nodes[it.id]
+		if !nd.closed && fr.seen[nd.key] == it.id {
+			return it, true
+		}
+		heap.Pop(&fr.open)
+	}
+	return openItem{}, false
+}
+
+// bytes estimates the memory held by fr. Map entries are counted at twice
+// their key and value size to cover the buckets around them.
+func (fr *frontier) bytes() int64 {
+	return int64(cap(fr.arena))*int64(unsafe.Sizeof(uint16(0))) +
+		int64(cap(fr.nodes))*int64(unsafe.Sizeof(node{})) +
+		int64(cap(fr.open))*int64(unsafe.Sizeof(openItem{})) +
+		int64(len(fr.seen))*2*int64(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(int32(0)))
+}
+
+// load makes node id the current board of fr.s.
+func (fr *frontier) load(id int32) {
+	fr.s.load(fr.arena[int(id)*fr.cells : (int(id)+1)*fr.cells])
+}
+
+// path returns the moves from the root of the frontier to node id.
+func (fr *frontier) path(id int32) []Move {
+	var path []Move
+	for ; fr.nodes[id].parent >= 0; id = fr.nodes[id].parent {
+		path = append(path, fr.nodes[id].move)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// successors calls visit for every board one blank move away from the current
+// board of fr.s, except the one undoing lastMove, restoring the board after
+// each call.
+func (fr *frontier) successors(lastMove Move, visit func(m Move)) {
+	s := fr.s
+	for _, m := range allMoves {
+		if m == lastMove.Reverse() {
+			continue
+		}
+		c := s.target(m)
+		if c < 0 {
+			continue
+		}
+		prev := s.blank
+		s.slide(c)
+		visit(m)
+		s.slide(prev)
+	}
+}
+
+// bestFirst is A* with f = g + weight*h. With weight 1 and a consistent
+// heuristic the first goal popped is optimal; with weight 1+epsilon the
+// result is at most (1+epsilon) times optimal, and closed boards are not
+// reopened. A positive bound drops every board with g + h >= bound, so only
+// solutions shorter than bound are found.
+type bestFirst struct {
+	weight float64
+	bound  int
+}
+
+func (bf bestFirst) Search(start, goal *state, lim *searchLimits) ([]Move, bool, Stats) {
+	var stats Stats
+	fr := newFrontier(start)
+	fr.add(0, -1, noMove, bf.weight*float64(start.h))
+	stats.Generated++
+	stats.LowerBound = start.h
+	var charged int64
+
+	for {
+		it, ok := fr.top()
+		if !ok {
+			stats.Memory = fr.bytes()
+			return nil, false, stats
+		}
+		if bf.weight == 1 {
+			stats.LowerBound = max(stats.LowerBound, int(it.prio))
+		}
+		if stats.Expanded&255 == 0 {
+			stop := lim.charge(stats.Generated-charged, fr.bytes())
+			charged = stats.Generated
+			if stop {
+				stats.Memory = fr.bytes()
+				return nil, false, stats
+			}
+		}
+		heap.Pop(&fr.open)
+
+		nd := &fr.nodes[it.id]
+		nd.closed = true
+		fr.load(it.id)
+		if fr.s.equals(goal) {
+			stats.Memory = fr.bytes()
+			return fr.path(it.id), true, stats
+		}
+
+		stats.Expanded++
+		g := int(nd.g) + 1
+		fr.successors(nd.move, func(m Move) {
+			stats.Generated++
+			if bf.bound > 0 && g+fr.s.h >= bf.bound {
+				return
+			}
+			if fr.improves(g, bf.weight == 1) {
+				fr.add(g, it.id, m, float64(g)+bf.weight*float64(fr.s.h))
+			}
+		})
+		if fr.open.Len() > stats.MaxFrontier {
+			stats.MaxFrontier = fr.open.Len()
+		}
+	}
+}
+
+// bidirectional is MM: A* from both ends, each side ordered by
+// max(g+h, 2g) and expanded from the side with the smaller minimum, stopping
+// once the best meeting cost found is no larger than that minimum. back is the
+// heuristic towards the start board used by the backward side.
+type bidirectional struct {
+	back heuristic
+}
+
+func (bd bidirectional) Search(start, goal *state, lim *searchLimits) ([]Move, bool, Stats) {
+	var stats Stats
+	if start.equals(goal) {
+		return nil, true, stats
+	}
+	stats.LowerBound = start.h
+	var charged int64
+
+	backStart := newState(goal.board())
+	backStart.setHeuristic(bd.back)
+	sides := [2]*frontier{newFrontier(start), newFrontier(backStart)}
+	priority := func(g, h int) float64 {
+		return float64(max(g+h, 2*g))
+	}
+	for _, fr := range sides {
+		fr.add(0, -1, noMove, priority(0, fr.s.h))
+		stats.Generated++
+	}
+
+	best, meet := -1, [2]int32{}
+	for {
+		topF, okF := sides[0].top()
+		topB, okB := sides[1].top()
+		if !okF || !okB {
+			break
+		}
+		side, it := 0, topF
+		if topB.prio < topF.prio {
+			side, it = 1, topB
+		}
+		if best >= 0 && float64(best) <= it.prio {
+			break
+		}
+		// MM never meets a solution cheaper than the smallest priority.
+		stats.LowerBound = max(stats.LowerBound, int(it.prio))
+		if stats.Expanded&255 == 0 {
+			stop := lim.charge(stats.Generated-charged, sides[0].bytes()+sides[1].bytes())
+			charged = stats.Generated
+			if stop {
+				break
+			}
+		}
+
+		fr, other := sides[side], sides[1-side]
+		heap.Pop(&fr.open)
+		nd := &fr.nodes[it.id]
+		nd.closed = true
+		fr.load(it.id)
+
+		stats.Expanded++
+		g := int(nd.g) + 1
+		fr.successors(nd.move, func(m Move) {
+			stats.Generated++
+			if !fr.improves(g, true) {
+				return
+			}
+			id := fr.add(g, it.id, m, priority(g, fr.s.h))
+			if oid, ok := other.seen[fr.s.key]; ok {
+				if cost := g + int(other.nodes[oid].g); best < 0 || cost < best {
+					best = cost
+					meet[side], meet[1-side] = id, oid
+				}
+			}
+		})
+		if size := sides[0].open.Len() + sides[1].open.Len(); size > stats.MaxFrontier {
+			stats.MaxFrontier = size
+		}
+	}
+
+	stats.Memory = sides[0].bytes() + sides[1].bytes()
+	if best < 0 || lim.Err() != nil {
+		return nil, false, stats
+	}
+	path := sides[0].path(meet[0])
+	backward := sides[1].path(meet[1])
+	for i := len(backward) - 1; i >= 0; i-- {
+		path = append(path, backward[i].Reverse())
+	}
+	return path, true, stats
+}
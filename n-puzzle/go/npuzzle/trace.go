@@ -0,0 +1,124 @@
+package npuzzle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tracer writes IDA* thresholds to W as they finish (if Iterations is set)
+// and, every Interval, a progress line for the running one. Lines are text
+// ("# iteration ...") or, with JSON, one object per line. All methods accept
+// a nil Tracer, and tick may be called from several workers at once.
+type Tracer struct {
+	W          io.Writer
+	JSON       bool
+	Iterations bool
+	Interval   time.Duration
+
+	mu        sync.Mutex
+	start     time.Time
+	iteration int
+	limit     int
+	last      int64
+	due       time.Time
+	nodes     atomic.Int64
+}
+
+// traceEvent is one line of output. Branching is the ratio of nodes expanded
+// in this threshold to the previous one, the usual estimate of the
+// heuristic branching factor (0 on the first threshold).
+type traceEvent struct {
+	Event     string  `json:"event"`
+	Iteration int     `json:"iteration"`
+	Limit     int     `json:"limit"`
+	Expanded  int64   `json:"expanded,omitempty"`
+	Generated int64   `json:"generated"`
+	Branching float64 `json:"branching,omitempty"`
+	NodesPerS float64 `json:"nodes_per_s,omitempty"`
+	ElapsedMs float64 `json:"time_ms"`
+}
+
+func (t *Tracer) emit(ev traceEvent) {
+	if t.JSON {
+		json.NewEncoder(t.W).Encode(ev)
+		return
+	}
+	switch ev.Event {
+	case "iteration":
+		fmt.Fprintf(t.W, "# iteration=%d limit=%d expanded=%d generated=%d branching=%.2f time_ms=%.3f\n",
+			ev.Iteration, ev.Limit, ev.Expanded, ev.Generated, ev.Branching, ev.ElapsedMs)
+	case "progress":
+		fmt.Fprintf(t.W, "# progress iteration=%d limit=%d generated=%d nodes_per_s=%.0f time_ms=%.3f\n",
+			ev.Iteration, ev.Limit, ev.Generated, ev.NodesPerS, ev.ElapsedMs)
+	}
+}
+
+// begin starts threshold iteration with the given limit.
+func (t *Tracer) begin(iteration, limit int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.start.IsZero() {
+		t.start = time.Now()
+		t.due = t.start.Add(t.Interval)
+	}
+	t.iteration, t.limit = iteration, limit
+}
+
+// tick counts 1024 more generated nodes and prints progress, with the nodes
+// generated since the search started, when it is due.
+func (t *Tracer) tick() {
+	if t == nil {
+		return
+	}
+	nodes := t.nodes.Add(1024)
+	if t.Interval <= 0 {
+		return
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Before(t.due) {
+		return
+	}
+	t.due = now.Add(t.Interval)
+	elapsed := now.Sub(t.start)
+	t.emit(traceEvent{
+		Event:     "progress",
+		Iteration: t.iteration,
+		Limit:     t.limit,
+		Generated: nodes,
+		NodesPerS: float64(nodes) / elapsed.Seconds(),
+		ElapsedMs: float64(elapsed.Nanoseconds()) / 1e6,
+	})
+}
+
+// end reports the threshold started by the last begin.
+func (t *Tracer) end(expanded, generated int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ev := traceEvent{
+		Event:     "iteration",
+		Iteration: t.iteration,
+		Limit:     t.limit,
+		Expanded:  expanded,
+		Generated: generated,
+		ElapsedMs: float64(time.Since(t.start).Nanoseconds()) / 1e6,
+	}
+	if t.last > 0 {
+		ev.Branching = float64(expanded) / float64(t.last)
+	}
+	t.last = expanded
+	if t.Iterations {
+		t.emit(ev)
+	}
+}
@@ -0,0 +1,226 @@
+package pdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// On-disk layout, all integers little endian:
+//
+//	magic    [4]byte  "NPDB"
+//	version  uint16
+//	rows     uint8
+//	cols     uint8
+//	tiles    uint8    number of pattern tiles k
+//	goal     [rows*cols]uint8
+//	pattern  [k]uint8
+//	entries  uint64
+//	dist     [entries]uint8
+//	checksum uint32   CRC-32C of everything before it
+const (
+	magic   = "NPDB"
+	version = 1
+)
+
+var (
+	ErrFormat   = errors.New("pdb: not a pattern database file")
+	ErrVersion  = errors.New("pdb: unsupported file version")
+	ErrChecksum = errors.New("pdb: checksum mismatch")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+func (t *Table) header() []byte {
+	buf := make([]byte, 0, 9+len(t.Goal)+len(t.Tiles)+8)
+	buf = append(buf, magic...)
+	buf = binary.LittleEndian.AppendUint16(buf, version)
+	buf = append(buf, uint8(t.Rows), uint8(t.Cols), uint8(len(t.Tiles)))
+	for _, tile := range t.Goal {
+		buf = append(buf, uint8(tile))
+	}
+	for _, tile := range t.Tiles {
+		buf = append(buf, uint8(tile))
+	}
+	return binary.LittleEndian.AppendUint64(buf, uint64(len(t.Dist)))
+}
+
+// WriteTo writes t in the versioned binary format.
+func (t *Table) WriteTo(w io.Writer) (int64, error) {
+	crc := crc32.New(castagnoli)
+	mw := io.MultiWriter(w, crc)
+
+	n, err := mw.Write(t.header())
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+	n, err = mw.Write(t.Dist)
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+	n, err = w.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32()))
+	return written + int64(n), err
+}
+
+// Save writes t to path, replacing the file only once it is complete. The
+// temporary file has a unique name, so processes saving the same table at
+// once do not write into each other's file.
+func (t *Table) Save(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	w := bufio.NewWriter(f)
+	_, err = t.WriteTo(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// parseHeader decodes the header at the start of data and returns the table
+// without distances plus the header length.
+func parseHeader(data []byte) (*Table, int, error) {
+	if len(data) < 9 || string(data[:4]) != magic {
+		return nil, 0, ErrFormat
+	}
+	if v := binary.LittleEndian.Uint16(data[4:]); v != version {
+		return nil, 0, fmt.Errorf("%w: %d", ErrVersion, v)
+	}
+	t := &Table{Rows: int(data[6]), Cols: int(data[7])}
+	cells, k := t.Rows*t.Cols, int(data[8])
+	size := 9 + cells + k + 8
+	if len(data) < size {
+		return nil, 0, ErrFormat
+	}
+
+	t.Goal = make([]int, cells)
+	for c := range t.Goal {
+		t.Goal[c] = int(data[9+c])
+	}
+	t.Tiles = make([]int, k)
+	for i := range t.Tiles {
+		t.Tiles[i] = int(data[9+cells+i])
+	}
+	if entries := binary.LittleEndian.Uint64(data[size-8:]); entries != uint64(placements(cells, k)) {
+		return nil, 0, fmt.Errorf("%w: %d entries for a %d-tile pattern on %dx%d", ErrFormat, entries, k, t.Rows, t.Cols)
+	}
+	return t, size, nil
+}
+
+// Read streams a table written by WriteTo and verifies its checksum.
+func Read(r io.Reader) (*Table, error) {
+	crc := crc32.New(castagnoli)
+	tr := io.TeeReader(r, crc)
+
+	fixed := make([]byte, 9)
+	if _, err := io.ReadFull(tr, fixed); err != nil {
+		return nil, ErrFormat
+	}
+	if string(fixed[:4]) != magic {
+		return nil, ErrFormat
+	}
+	rest := make([]byte, int(fixed[6])*int(fixed[7])+int(fixed[8])+8)
+	if _, err := io.ReadFull(tr, rest); err != nil {
+		return nil, ErrFormat
+	}
+	t, _, err := parseHeader(append(fixed, rest...))
+	if err != nil {
+		return nil, err
+	}
+
+	t.Dist = make([]uint8, placements(t.Rows*t.Cols, len(t.Tiles)))
+	if _, err := io.ReadFull(tr, t.Dist); err != nil {
+		return nil, ErrFormat
+	}
+	sum := make([]byte, 4)
+	if _, err := io.ReadFull(r, sum); err != nil {
+		return nil, ErrFormat
+	}
+	if binary.LittleEndian.Uint32(sum) != crc.Sum32() {
+		return nil, ErrChecksum
+	}
+	return t, nil
+}
+
+// Open loads the table at path, memory-mapping it where the platform allows
+// and streaming it otherwise. The checksum is verified either way.
+func Open(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, unmap, err := mmapFile(f)
+	if err != nil {
+		return Read(bufio.NewReader(f))
+	}
+
+	t, size, err := parseHeader(data)
+	if err == nil && len(data) != size+placements(t.Rows*t.Cols, len(t.Tiles))+4 {
+		err = ErrFormat
+	}
+	if err == nil {
+		end := len(data) - 4
+		if binary.LittleEndian.Uint32(data[end:]) != crc32.Checksum(data[:end], castagnoli) {
+			err = ErrChecksum
+		}
+	}
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+
+	t.Dist = data[size : len(data)-4]
+	t.unmap = unmap
+	return t, nil
+}
+
+// Close releases the memory mapping of a table returned by Open.
+func (t *Table) Close() error {
+	if t.unmap == nil {
+		return nil
+	}
+	err := t.unmap()
+	t.unmap, t.Dist = nil, nil
+	return err
+}
+
+// Matches reports whether t was built for the given board, goal and pattern.
+func (t *Table) Matches(rows, cols int, goal []int, tiles []int) bool {
+	if t.Rows != rows || t.Cols != cols || len(t.Goal) != len(goal) || len(t.Tiles) != len(tiles) {
+		return false
+	}
+	for c := range goal {
+		if t.Goal[c] != goal[c] {
+			return false
+		}
+	}
+	for i := range tiles {
+		if t.Tiles[i] != tiles[i] {
+			return false
+		}
+	}
+	return true
+}
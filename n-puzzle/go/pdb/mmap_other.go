@@ -0,0 +1,13 @@
+//go:build !unix
+
+package pdb
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is unavailable here; Open falls back to streaming the file.
+func mmapFile(f *os.File) ([]byte, func() error, error) {
+	return nil, nil, errors.New("pdb: memory mapping not supported")
+}
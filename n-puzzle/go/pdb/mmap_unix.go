@@ -0,0 +1,27 @@
+//go:build unix
+
+package pdb
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// mmapFile maps f read-only and returns its contents and a function that
+// releases the mapping.
+func mmapFile(f *os.File) ([]byte, func() error, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size <= 0 || int64(int(size)) != size {
+		return nil, nil, errors.New("pdb: cannot map file")
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
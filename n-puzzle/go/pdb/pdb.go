@@ -0,0 +1,334 @@
+// Package pdb builds additive pattern databases for sliding-tile puzzles.
+//
+// A table covers a subset of the tiles (the pattern). For every placement of
+// the pattern tiles it stores the fewest moves of pattern tiles needed to put
+// them on their goal cells, with the other tiles treated as indistinguishable
+// and free to move. Tables over disjoint patterns can therefore be added
+// together and the sum is still admissible.
+package pdb
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const unset = 0xff
+
+// Goal returns the sorted goal the solver builds from its blank index input:
+// tiles 1..cells-1 in reading order and the blank last, then the blank
+// swapped with the tile at index blank (-1 leaves it last).
+func Goal(cells, blank int) []int {
+	goal := make([]int, cells)
+	for k := 0; k < cells-1; k++ {
+		goal[k] = k + 1
+	}
+	if blank >= 0 && blank < cells {
+		goal[cells-1], goal[blank] = goal[blank], goal[cells-1]
+	}
+	return goal
+}
+
+// Partition splits the tiles of goal, in reading order, into groups of the
+// sizes in spec ("6-6-3"). An empty spec uses groups of at most six.
+func Partition(goal []int, spec string) ([][]int, error) {
+	var order []int
+	for _, tile := range goal {
+		if tile != 0 {
+			order = append(order, tile)
+		}
+	}
+
+	var sizes []int
+	if spec == "" {
+		for left := len(order); left > 0; left -= 6 {
+			sizes = append(sizes, min(left, 6))
+		}
+	} else {
+		total := 0
+		for _, part := range strings.Split(spec, "-") {
+			size, err := strconv.Atoi(part)
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("pdb: invalid partition %q", spec)
+			}
+			sizes = append(sizes, size)
+			total += size
+		}
+		if total != len(order) {
+			return nil, fmt.Errorf("pdb: partition %q covers %d tiles, board has %d", spec, total, len(order))
+		}
+	}
+
+	groups := make([][]int, len(sizes))
+	for k, size := range sizes {
+		groups[k], order = order[:size], order[size:]
+	}
+	return groups, nil
+}
+
+// DefaultDir is where tables are cached when no directory is given.
+func DefaultDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "n-puzzle-pdb")
+	}
+	return filepath.Join(os.TempDir(), "n-puzzle-pdb")
+}
+
+// FileName names the table of tiles for a board size and goal. The goal is
+// folded into a short hash; Open still checks the full goal stored inside.
+func FileName(rows, cols int, goal []int, tiles []int) string {
+	h := fnv.New32a()
+	for _, tile := range goal {
+		fmt.Fprintf(h, "%d,", tile)
+	}
+	parts := make([]string, len(tiles))
+	for k, tile := range tiles {
+		parts[k] = strconv.Itoa(tile)
+	}
+	return fmt.Sprintf("%dx%d-%08x-%s.pdb", rows, cols, h.Sum32(), strings.Join(parts, "_"))
+}
+
+// Table is the pattern database of one tile subset for one board size and
+// goal.
+type Table struct {
+	Rows, Cols int
+	Goal       []int
+	Tiles      []int
+	Dist       []uint8
+
+	unmap func() error
+}
+
+// placements returns cells!/(cells-k)!, the number of ways to put k distinct
+// tiles on the board.
+func placements(cells, k int) int {
+	size := 1
+	for i := 0; i < k; i++ {
+		size *= cells - i
+	}
+	return size
+}
+
+// rank maps the cells of the pattern tiles (pos[i] holds Tiles[i]) to a dense
+// index in [0, placements).
+func rank(cells int, pos []int) int {
+	idx := 0
+	for i, p := range pos {
+		d := p
+		for j := 0; j < i; j++ {
+			if pos[j] < p {
+				d--
+			}
+		}
+		idx = idx*(cells-i) + d
+	}
+	return idx
+}
+
+// unrank is the inverse of rank. It returns the occupied cells as a bit mask.
+func unrank(cells, idx int, pos []int) uint64 {
+	for i := len(pos) - 1; i >= 0; i-- {
+		r := cells - i
+		pos[i] = idx % r
+		idx /= r
+	}
+
+	var used uint64
+	for i := range pos {
+		d := pos[i]
+		c := 0
+		for {
+			if used&(1<<c) == 0 {
+				if d == 0 {
+					break
+				}
+				d--
+			}
+			c++
+		}
+		pos[i] = c
+		used |= 1 << c
+	}
+	return used
+}
+
+// Index returns the table index of the board where cellOf[tile] is the cell
+// holding tile.
+func (t *Table) Index(cellOf []int) int {
+	cells := t.Rows * t.Cols
+	idx := 0
+	for i, tile := range t.Tiles {
+		p := cellOf[tile]
+		d := p
+		for j := 0; j < i; j++ {
+			if cellOf[t.Tiles[j]] < p {
+				d--
+			}
+		}
+		idx = idx*(cells-i) + d
+	}
+	return idx
+}
+
+// Lookup returns the stored distance of the board where cellOf[tile] is the
+// cell holding tile.
+func (t *Table) Lookup(cellOf []int) int {
+	return int(t.Dist[t.Index(cellOf)])
+}
+
+type region struct {
+	placement uint32
+	blanks    uint64
+}
+
+// Build runs a backward breadth-first search from goal (row-major, 0 for the
+// blank) over placements of tiles. Moves of other tiles cost nothing, so each
+// layer is first closed over the cells the blank can reach for free and only
+// then expanded by one pattern-tile move. It returns ctx.Err() if ctx ends
+// first.
+func Build(ctx context.Context, rows, cols int, goal []int, tiles []int) (*Table, error) {
+	cells := rows * cols
+	if cells > 64 {
+		return nil, fmt.Errorf("pdb: boards larger than 64 cells are not supported")
+	}
+	if len(goal) != cells {
+		return nil, fmt.Errorf("pdb: goal has %d cells, want %d", len(goal), cells)
+	}
+	size := placements(cells, len(tiles))
+	if uint64(size)*uint64(cells) > 1<<32 {
+		return nil, fmt.Errorf("pdb: pattern of %d tiles on %dx%d is too large", len(tiles), rows, cols)
+	}
+
+	cellOf := make([]int, cells)
+	blank := -1
+	for c, tile := range goal {
+		if tile < 0 || tile >= cells {
+			return nil, fmt.Errorf("pdb: invalid tile %d in goal", tile)
+		}
+		cellOf[tile] = c
+		if tile == 0 {
+			blank = c
+		}
+	}
+	if blank < 0 {
+		return nil, fmt.Errorf("pdb: goal has no blank")
+	}
+	for _, tile := range tiles {
+		if tile <= 0 || tile >= cells {
+			return nil, fmt.Errorf("pdb: invalid pattern tile %d", tile)
+		}
+	}
+
+	adj := make([][]int, cells)
+	for c := 0; c < cells; c++ {
+		r, k := c/cols, c%cols
+		if r > 0 {
+			adj[c] = append(adj[c], c-cols)
+		}
+		if r < rows-1 {
+			adj[c] = append(adj[c], c+cols)
+		}
+		if k > 0 {
+			adj[c] = append(adj[c], c-1)
+		}
+		if k < cols-1 {
+			adj[c] = append(adj[c], c+1)
+		}
+	}
+
+	t := &Table{
+		Rows:  rows,
+		Cols:  cols,
+		Goal:  append([]int(nil), goal...),
+		Tiles: append([]int(nil), tiles...),
+		Dist:  make([]uint8, size),
+	}
+	for i := range t.Dist {
+		t.Dist[i] = unset
+	}
+
+	// visited marks (placement, blank) pairs that were queued, closed marks
+	// those whose free blank region was already flooded.
+	visited := make([]uint64, (size*cells+63)/64)
+	closed := make([]uint64, (size*cells+63)/64)
+	test := func(set []uint64, id int) bool { return set[id/64]&(1<<(id%64)) != 0 }
+	mark := func(set []uint64, id int) { set[id/64] |= 1 << (id % 64) }
+
+	start := t.Index(cellOf)*cells + blank
+	mark(visited, start)
+	cur := []uint32{uint32(start)}
+	pos := make([]int, len(tiles))
+	stack := make([]int, 0, cells)
+
+	for d := 0; len(cur) > 0; d++ {
+		if d >= unset {
+			return nil, fmt.Errorf("pdb: distance exceeds %d", unset-1)
+		}
+
+		var layer []region
+		for k, id := range cur {
+			if k%(1<<16) == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if test(closed, int(id)) {
+				continue
+			}
+			p, b := int(id)/cells, int(id)%cells
+			if t.Dist[p] == unset {
+				t.Dist[p] = uint8(d)
+			}
+			used := unrank(cells, p, pos)
+
+			blanks := uint64(1) << b
+			stack = append(stack[:0], b)
+			for len(stack) > 0 {
+				c := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				mark(visited, p*cells+c)
+				mark(closed, p*cells+c)
+				for _, nb := range adj[c] {
+					if used&(1<<nb) == 0 && blanks&(1<<nb) == 0 {
+						blanks |= 1 << nb
+						stack = append(stack, nb)
+					}
+				}
+			}
+			layer = append(layer, region{uint32(p), blanks})
+		}
+
+		var next []uint32
+		for k, reg := range layer {
+			if k%(1<<16) == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			used := unrank(cells, int(reg.placement), pos)
+			for blanks := reg.blanks; blanks != 0; blanks &= blanks - 1 {
+				b := bits.TrailingZeros64(blanks)
+				for _, nb := range adj[b] {
+					if used&(1<<nb) == 0 {
+						continue
+					}
+					i := 0
+					for pos[i] != nb {
+						i++
+					}
+					pos[i] = b
+					id := rank(cells, pos)*cells + nb
+					pos[i] = nb
+					if !test(visited, id) {
+						mark(visited, id)
+						next = append(next, uint32(id))
+					}
+				}
+			}
+		}
+		cur = next
+	}
+
+	return t, nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// openTerminal is unavailable here; -step needs a Unix terminal.
+func openTerminal() (*os.File, func(), error) {
+	return nil, nil, errors.New("-step needs a Unix terminal")
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// openTerminal opens the controlling terminal for reading single keypresses:
+// stty switches it to cbreak mode without echo, and restore switches it back.
+func openTerminal() (tty *os.File, restore func(), err error) {
+	tty, err = os.Open("/dev/tty")
+	if err != nil {
+		return nil, nil, err
+	}
+	saved, err := stty(tty, "-g")
+	if err == nil {
+		_, err = stty(tty, "cbreak", "-echo")
+	}
+	if err != nil {
+		tty.Close()
+		return nil, nil, err
+	}
+	return tty, func() {
+		stty(tty, strings.TrimSpace(saved))
+		tty.Close()
+	}, nil
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
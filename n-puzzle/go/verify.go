@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"algorithms-solutions/n-puzzle/go/npuzzle"
+)
+
+// verifyMain implements "n-puzzle verify": it checks a move list in the
+// solver's output format against the puzzle on stdin. The exit status is 0
+// when the answer is correct, 1 when it is not and 2 on malformed input.
+func verifyMain(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	movesPath := fs.String("moves", "", "file holding the answer to check, in the solver's output format (required)")
+	goalInput := fs.Bool("goal", false, "read an explicit goal board after the start board instead of the blank index line")
+	optimal := fs.Bool("optimal", false, "also solve the puzzle and check that the answer is a shortest one")
+	heuristicName := fs.String("heuristic", "manhattan", "heuristic used by -optimal: "+strings.Join(npuzzle.HeuristicNames, ", "))
+	style := fs.String("notation", "words", "move notation of the answer, as for the solver: words, letters or numbers")
+	relative := fs.String("relative", "tile", "directions of the answer name the move of the slid tile or of the blank")
+	fs.Parse(args)
+	if *movesPath == "" {
+		fmt.Fprintln(os.Stderr, "verify: -moves is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+	notation, err := parseNotation(*style, *relative)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "verify:", err)
+		os.Exit(2)
+	}
+
+	start, goal, err := npuzzle.ParseInput(os.Stdin, *goalInput)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "puzzle:", err)
+		os.Exit(2)
+	}
+	f, err := os.Open(*movesPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	answer, err := npuzzle.ParseAnswer(f, notation)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *movesPath, err)
+		os.Exit(2)
+	}
+
+	if answer.Unsolvable {
+		if npuzzle.IsSolvable(start, goal) {
+			fmt.Println("invalid: the answer is -1 but the goal is reachable")
+			os.Exit(1)
+		}
+		fmt.Println("valid: the goal is unreachable")
+		return
+	}
+
+	reached, err := answer.Replay(start, goal)
+	if err != nil {
+		fmt.Printf("invalid: %s: %v\n", *movesPath, err)
+		os.Exit(1)
+	}
+	if !reached {
+		fmt.Printf("invalid: %d moves do not reach the goal\n", len(answer.Moves))
+		os.Exit(1)
+	}
+	fmt.Printf("valid: %d moves reach the goal\n", len(answer.Moves))
+
+	if *optimal {
+		solution, err := npuzzle.Solve(context.Background(), start, goal, npuzzle.Options{Heuristic: *heuristicName})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		best := len(solution.Moves)
+		if best < len(answer.Moves) {
+			fmt.Printf("not optimal: the shortest solution has %d moves\n", best)
+			os.Exit(1)
+		}
+		fmt.Println("optimal")
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"strings"
+	"time"
+)
+
+// Boards up to backtrackN are completed by backtracking alone, so the answer
+// is exact there. Boards up to maxCountN fall back to it when Min-Conflicts
+// gives up, but only for fallbackNodes placements, as the search can take
+// far longer than anyone would wait; larger boards can only give up.
+const (
+	backtrackN    = 16
+	fallbackNodes = 1 << 25
+)
+
+var (
+	errInfeasible = errors.New("no completion exists")
+	errGaveUp     = errors.New("no completion found")
+)
+
+// constraints are the queens placed in advance and the cells no queen may
+// take: fixed holds the row of the queen fixed in each column, or -1, and
+// forbidden the cells col*n+row.
+type constraints struct {
+	fixed     []int
+	forbidden map[int]bool
+}
+
+// readConstraints reads a board of N lines of N cells: '.' is free, 'Q' a
+// fixed queen and 'x' a forbidden cell. Rows are lines, columns characters.
+func readConstraints(r io.Reader) (*constraints, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<26)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	n := len(lines)
+	if n == 0 {
+		return nil, errors.New("empty board")
+	}
+
+	cons := &constraints{fixed: make([]int, n), forbidden: make(map[int]bool)}
+	for col := range cons.fixed {
+		cons.fixed[col] = -1
+	}
+	for row, line := range lines {
+		if len(line) != n {
+			return nil, fmt.Errorf("line %d has %d cells, want %d", row+1, len(line), n)
+		}
+		for col := 0; col < n; col++ {
+			switch line[col] {
+			case '.':
+			case 'Q', 'q':
+				if cons.fixed[col] >= 0 {
+					return nil, fmt.Errorf("line %d: column %d already has a queen", row+1, col)
+				}
+				cons.fixed[col] = row
+			case 'x', 'X':
+				cons.forbidden[col*n+row] = true
+			default:
+				return nil, fmt.Errorf("line %d: unknown cell %q (want '.', 'Q' or 'x')", row+1, line[col])
+			}
+		}
+	}
+	return cons, nil
+}
+
+// check reports the conflicts visible without search: fixed queens on
+// forbidden cells or attacking each other, and free columns with every cell
+// forbidden.
+func (cons *constraints) check() error {
+	n := len(cons.fixed)
+	for a, ra := range cons.fixed {
+		if ra < 0 {
+			forbidden := 0
+			for row := 0; row < n; row++ {
+				if cons.forbidden[a*n+row] {
+					forbidden++
+				}
+			}
+			if forbidden == n {
+				return fmt.Errorf("%w: every cell of column %d is forbidden", errInfeasible, a)
+			}
+			continue
+		}
+		if cons.forbidden[a*n+ra] {
+			return fmt.Errorf("%w: the fixed queen of column %d is on a forbidden cell", errInfeasible, a)
+		}
+		for b := a + 1; b < n; b++ {
+			if rb := cons.fixed[b]; rb >= 0 && (ra == rb || ra-rb == a-b || ra-rb == b-a) {
+				return fmt.Errorf("%w: the fixed queens of columns %d and %d attack each other", errInfeasible, a, b)
+			}
+		}
+	}
+	return nil
+}
+
+// completeBacktrack searches the completions column by column with bit
+// masks, as eachSolution does, and returns the first or nil if there is
+// none. The rows of the fixed queens are taken from the start. With
+// maxNodes > 0 it stops after placing that many queens and reports that it
+// ran out.
+func completeBacktrack(cons *constraints, maxNodes int64) (sol []int, ranOut bool) {
+	n := len(cons.fixed)
+	all := uint64(1)<<n - 1
+	blocked := make([]uint64, n)
+	for cell := range cons.forbidden {
+		blocked[cell/n] |= 1 << (cell % n)
+	}
+	var fixedRows uint64
+	for _, row := range cons.fixed {
+		if row >= 0 {
+			fixedRows |= 1 << row
+		}
+	}
+
+	sol = make([]int, n)
+	var nodes int64
+	var place func(col int, rows, up, down uint64) bool
+	place = func(col int, rows, up, down uint64) bool {
+		if col == n {
+			return true
+		}
+		if nodes++; maxNodes > 0 && nodes > maxNodes {
+			ranOut = true
+			return true
+		}
+		free := all &^ (rows | up | down | blocked[col])
+		if row := cons.fixed[col]; row >= 0 {
+			free = uint64(1) << row &^ (up | down)
+		}
+		for free != 0 {
+			bit := free & -free
+			free ^= bit
+			sol[col] = bits.TrailingZeros64(bit)
+			if place(col+1, rows|bit, (up|bit)<<1, (down|bit)>>1) {
+				return true
+			}
+		}
+		return false
+	}
+	if !place(0, fixedRows, 0, 0) || ranOut {
+		return nil, ranOut
+	}
+	return sol, false
+}
+
+// completeNQueens places a queen in every free column so that no two
+// attack each other, keeping the fixed ones and avoiding forbidden cells.
+// It returns an error wrapping errInfeasible if there is no such board and
+// errGaveUp if neither Min-Conflicts within maxSteps (0 for
+// max(100n, 50000), more than solveNQueens takes as fixed queens make the
+// search harder) nor the bounded backtracking after it found one on a board
+// too large to prove it. seed is passed to newSolver.
+func completeNQueens(cons *constraints, maxSteps int, seed int64) ([]int, error) {
+	if err := cons.check(); err != nil {
+		return nil, err
+	}
+	n := len(cons.fixed)
+	var maxNodes int64
+	if n > backtrackN {
+		if maxSteps == 0 {
+			maxSteps = max(100*n, 50000)
+		}
+		if sol := newSolver(n, seed, cons).solve(maxSteps); sol != nil {
+			return sol, nil
+		}
+		if n > maxCountN {
+			return nil, fmt.Errorf("%w: min-conflicts ran out of %d steps", errGaveUp, maxSteps)
+		}
+		maxNodes = fallbackNodes
+	}
+	sol, ranOut := completeBacktrack(cons, maxNodes)
+	switch {
+	case ranOut:
+		return nil, fmt.Errorf("%w: min-conflicts ran out of %d steps and backtracking of %d placements", errGaveUp, maxSteps, maxNodes)
+	case sol == nil:
+		return nil, errInfeasible
+	}
+	return sol, nil
+}
+
+// completeMain implements "n-queens complete": it reads a board with fixed
+// queens and forbidden cells from stdin and prints the time line, then the
+// completed board as a single answer or -1 if there is none.
+func completeMain(args []string) {
+	fs := flag.NewFlagSet("complete", flag.ExitOnError)
+	maxSteps := fs.Int("max-steps", 0, "Min-Conflicts steps before falling back to backtracking or giving up (0 for max(100N, 50000))")
+	seed := fs.Int64("seed", 0, "seed of the Min-Conflicts run (default: from the clock, printed to repeat the run)")
+	fs.Parse(args)
+	if !isFlagSet(fs, "seed") {
+		*seed = time.Now().UnixNano()
+	}
+	cons, err := readConstraints(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "complete:", err)
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	result, err := completeNQueens(cons, *maxSteps, *seed)
+	elapsed := time.Since(start)
+
+	timeOnly := os.Getenv("FMI_TIME_ONLY") == "1"
+	fmt.Printf("# TIMES_MS: alg=%.3f\n", float64(elapsed.Nanoseconds())/1e6)
+	if !timeOnly {
+		fmt.Printf("# SEED: %d\n", *seed)
+	}
+	switch {
+	case errors.Is(err, errGaveUp):
+		fmt.Fprintln(os.Stderr, "# gave up:", err)
+		os.Exit(3)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "#", err)
+		if !timeOnly {
+			fmt.Println(-1)
+		}
+	case !timeOnly:
+		fmt.Println(result)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// valid reports whether sol keeps the fixed queens, avoids the forbidden
+// cells and has no two queens attacking each other.
+func valid(sol []int, cons *constraints) bool {
+	n := len(cons.fixed)
+	if len(sol) != n {
+		return false
+	}
+	for c, r := range sol {
+		if cons.fixed[c] >= 0 && cons.fixed[c] != r || cons.forbidden[c*n+r] {
+			return false
+		}
+		for d := c + 1; d < n; d++ {
+			if sol[d] == r || sol[d]-r == d-c || r-sol[d] == d-c {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestCompleteNQueens(t *testing.T) {
+	cons, err := readConstraints(strings.NewReader(".Q..\n....\n....\nx...\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sol, err := completeNQueens(cons, 0, 1); err != nil || !valid(sol, cons) {
+		t.Errorf("4x4: got %v, %v", sol, err)
+	}
+
+	// No 4-queens solution has a queen in a corner, and the two fixed
+	// queens of the 40x40 board attack each other along a diagonal.
+	corner, _ := readConstraints(strings.NewReader("Q...\n....\n....\n....\n"))
+	diagonal := &constraints{fixed: make([]int, 40), forbidden: map[int]bool{}}
+	for col := range diagonal.fixed {
+		diagonal.fixed[col] = -1
+	}
+	diagonal.fixed[3], diagonal.fixed[10] = 5, 12
+	for _, cons := range []*constraints{corner, diagonal} {
+		if sol, err := completeNQueens(cons, 0, 1); !errors.Is(err, errInfeasible) {
+			t.Errorf("%d queens: got %v, %v, want errInfeasible", len(cons.fixed), sol, err)
+		}
+	}
+
+	// The last column of the 40x40 board has one cell left, in the row of
+	// a fixed queen, which only a search through every other column finds.
+	hidden := &constraints{fixed: make([]int, 40), forbidden: map[int]bool{}}
+	for col := range hidden.fixed {
+		hidden.fixed[col] = -1
+	}
+	hidden.fixed[0] = 0
+	for row := 1; row < 40; row++ {
+		hidden.forbidden[39*40+row] = true
+	}
+	if sol, err := completeNQueens(hidden, 1000, 1); !errors.Is(err, errGaveUp) {
+		t.Errorf("hidden conflict: got %v, %v, want errGaveUp", sol, err)
+	}
+
+	// Min-Conflicts on larger boards keeps a solution's first columns
+	// and avoids random forbidden cells.
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{30, 100} {
+		cons := &constraints{fixed: make([]int, n), forbidden: map[int]bool{}}
+		base := constructiveSolution(n)
+		for col := range cons.fixed {
+			cons.fixed[col] = -1
+			if col < n/4 {
+				cons.fixed[col] = base[col]
+			}
+		}
+		for k := 0; k < n; k++ {
+			if cell := rng.Intn(n * n); cons.fixed[cell/n] < 0 {
+				cons.forbidden[cell] = true
+			}
+		}
+		sol, err := completeNQueens(cons, 0, 1)
+		if err != nil || !valid(sol, cons) {
+			t.Errorf("n=%d: got %v, %v", n, sol, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/bits"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// maxCountN is the largest board the counter handles: the attacked columns
+// and diagonals of a row are kept in 64-bit masks.
+const maxCountN = 64
+
+// counter enumerates the solutions of the n-queens problem by bitmask
+// backtracking, one row at a time: cols, left and right are the columns
+// attacked in the current row by a queen above, along a column or a
+// diagonal. Row 0 only gets the left half of the columns (and the middle
+// one for odd n), each solution found there standing for itself and its
+// mirror image: weight is 2, or 1 for the middle.
+type counter struct {
+	n           int
+	all         uint64
+	sol         []int
+	inv         []int
+	weight      uint64
+	total       uint64
+	fundamental uint64
+}
+
+func newCounter(n int) *counter {
+	return &counter{n: n, all: 1<<n - 1, sol: make([]int, n), inv: make([]int, n)}
+}
+
+// run counts the solutions that start with the queens of prefix, given row
+// by row and not attacking each other.
+func (c *counter) run(prefix []int) {
+	c.weight = 2
+	if 2*prefix[0]+1 == c.n {
+		c.weight = 1
+	}
+	var cols, left, right uint64
+	for row, col := range prefix {
+		bit := uint64(1) << col
+		c.sol[row] = col
+		cols, left, right = cols|bit, (left|bit)<<1, (right|bit)>>1
+	}
+	c.place(len(prefix), cols, left, right)
+}
+
+// place fills the rows from row on.
+func (c *counter) place(row int, cols, left, right uint64) {
+	if row == c.n {
+		c.total += c.weight
+		if c.canonical() {
+			c.fundamental++
+		}
+		return
+	}
+	free := c.all &^ (cols | left | right)
+	for free != 0 {
+		bit := free & -free
+		free ^= bit
+		c.sol[row] = bits.TrailingZeros64(bit)
+		c.place(row+1, cols|bit, (left|bit)<<1, (right|bit)>>1)
+	}
+}
+
+// canonical reports whether sol is the smallest of its 8 images under the
+// symmetries of the board, compared as sequences of columns, so every
+// fundamental solution is counted once. The images read sol or its inverse
+// (the transpose) with the rows and the columns each reversed or not; the
+// smallest has its first queen in the left half, so it is enumerated.
+func (c *counter) canonical() bool {
+	n := c.n
+	for r, col := range c.sol {
+		c.inv[col] = r
+	}
+	for t := 1; t < 8; t++ {
+		src := c.sol
+		if t&4 != 0 {
+			src = c.inv
+		}
+		for r := 0; r < n; r++ {
+			v := src[r]
+			if t&1 != 0 {
+				v = src[n-1-r]
+			}
+			if t&2 != 0 {
+				v = n - 1 - v
+			}
+			if v != c.sol[r] {
+				if v < c.sol[r] {
+					return false
+				}
+				break
+			}
+		}
+	}
+	return true
+}
+
+// splitSearch cuts the search into independent tasks by the queens of the
+// first two rows (the first one alone on a 1×1 board), the first in the
+// left half.
+func splitSearch(n int) [][]int {
+	var tasks [][]int
+	for col := 0; col < (n+1)/2; col++ {
+		if n == 1 {
+			tasks = append(tasks, []int{col})
+		}
+		for next := 0; next < n; next++ {
+			if next < col-1 || next > col+1 {
+				tasks = append(tasks, []int{col, next})
+			}
+		}
+	}
+	return tasks
+}
+
+// countSolutions returns the number of solutions on an n×n board and the
+// number of fundamental ones, counting those that are rotations or
+// reflections of each other once. The tasks of splitSearch are shared by
+// workers goroutines and their counts added up in task order. progress, if
+// not nil, is called after every task with the number done so far.
+func countSolutions(n, workers int, progress func(done, tasks int)) (total, fundamental uint64) {
+	tasks := splitSearch(n)
+	counts := make([][2]uint64, len(tasks))
+	next := make(chan int)
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := newCounter(n)
+			for k := range next {
+				c.total, c.fundamental = 0, 0
+				c.run(tasks[k])
+				counts[k] = [2]uint64{c.total, c.fundamental}
+				if progress != nil {
+					mu.Lock()
+					done++
+					progress(done, len(tasks))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for k := range tasks {
+		next <- k
+	}
+	close(next)
+	wg.Wait()
+
+	for _, count := range counts {
+		total += count[0]
+		fundamental += count[1]
+	}
+	return total, fundamental
+}
+
+// countMain implements "n-queens count N": it prints the time line, then
+// the number of solutions and the number of fundamental solutions.
+func countMain(args []string) {
+	fs := flag.NewFlagSet("count", flag.ExitOnError)
+	workers := fs.Int("workers", runtime.NumCPU(), "goroutines sharing the search")
+	interval := fs.Duration("progress", 0, "print the finished tasks to stderr at most this often (e.g. 10s)")
+	fs.Parse(args)
+	n := readN(fs.Arg(0))
+	if n < 1 || n > maxCountN {
+		fmt.Fprintf(os.Stderr, "count: N must be between 1 and %d, got %d\n", maxCountN, n)
+		os.Exit(1)
+	}
+	if *workers < 1 {
+		fmt.Fprintf(os.Stderr, "count: workers must be positive, got %d\n", *workers)
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	var progress func(done, tasks int)
+	if *interval > 0 {
+		last := start
+		progress = func(done, tasks int) {
+			if now := time.Now(); now.Sub(last) >= *interval || done == tasks {
+				last = now
+				fmt.Fprintf(os.Stderr, "# progress tasks=%d/%d time_ms=%.3f\n", done, tasks, float64(now.Sub(start).Nanoseconds())/1e6)
+			}
+		}
+	}
+	total, fundamental := countSolutions(n, *workers, progress)
+	elapsed := time.Since(start)
+
+	fmt.Printf("# TIMES_MS: alg=%.3f\n", float64(elapsed.Nanoseconds())/1e6)
+	if os.Getenv("FMI_TIME_ONLY") != "1" {
+		fmt.Println(total)
+		fmt.Println(fundamental)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+// OEIS A000170 and A002562: the number of solutions and of fundamental
+// solutions for n = 1, 2, ...
+var (
+	knownTotals       = []uint64{1, 0, 0, 2, 10, 4, 40, 92, 352, 724, 2680, 14200, 73712, 365596, 2279184, 14772512}
+	knownFundamentals = []uint64{1, 0, 0, 1, 2, 1, 6, 12, 46, 92, 341, 1787, 9233, 45752, 285053, 1846955}
+)
+
+func TestCountSolutions(t *testing.T) {
+	maxN := len(knownTotals)
+	if testing.Short() {
+		maxN = 12
+	}
+	for n := 1; n <= maxN; n++ {
+		for _, workers := range []int{1, max(runtime.NumCPU(), 4)} {
+			if workers == 1 && n > 12 {
+				continue
+			}
+			total, fundamental := countSolutions(n, workers, nil)
+			if total != knownTotals[n-1] || fundamental != knownFundamentals[n-1] {
+				t.Errorf("n=%d, %d workers: %d solutions, %d fundamental, want %d and %d", n, workers, total, fundamental, knownTotals[n-1], knownFundamentals[n-1])
+			}
+		}
+	}
+}
+
+func BenchmarkCountSolutions(b *testing.B) {
+	for k := 0; k < b.N; k++ {
+		countSolutions(12, 1, nil)
+	}
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"time"
 )
@@ -81,9 +83,16 @@ type Solver struct {
 	conflictedList []int
 	offset         int
 	rng            *rand.Rand
+	steps          int          // steps taken by solve
+	fixed          []bool       // columns whose queen never moves
+	free           []int        // the other columns
+	forbidden      map[int]bool // cells col*n+row no queen may take
 }
 
-func newSolver(n int) *Solver {
+// newSolver starts from a random permutation drawn from seed; the same seed
+// replays the same run. cons, if not nil, places the fixed queens first and
+// forbids its cells; see constraints.check.
+func newSolver(n int, seed int64, cons *constraints) *Solver {
 	s := &Solver{
 		n:              n,
 		state:          make([]int, n),
@@ -94,13 +103,38 @@ func newSolver(n int) *Solver {
 		conflictedSet:  make(map[int]bool),
 		conflictedList: make([]int, 0, n),
 		offset:         n,
-		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:            rand.New(rand.NewSource(seed)),
+		fixed:          make([]bool, n),
 	}
 
 	// Initialize with random permutation
 	perm := s.rng.Perm(n)
 	copy(s.state, perm)
 
+	// Swap the fixed queens into place; fixed rows are distinct, so a fixed
+	// column never gives its row away again
+	if cons != nil {
+		s.forbidden = cons.forbidden
+		colOf := make([]int, n)
+		for col, row := range s.state {
+			colOf[row] = col
+		}
+		for col, row := range cons.fixed {
+			if row < 0 {
+				continue
+			}
+			other := colOf[row]
+			s.state[col], s.state[other] = row, s.state[col]
+			colOf[s.state[other]], colOf[row] = other, col
+			s.fixed[col] = true
+		}
+	}
+	for col := 0; col < n; col++ {
+		if !s.fixed[col] {
+			s.free = append(s.free, col)
+		}
+	}
+
 	// Build initial counts
 	for i := 0; i < n; i++ {
 		s.rowCounts[i] = 1 // permutation guarantees 1 per row
@@ -114,7 +148,7 @@ func newSolver(n int) *Solver {
 	for col, row := range s.state {
 		c := s.computeConflicts(col, row)
 		s.conflicts[col] = c
-		if c > 0 {
+		if c > 0 && !s.fixed[col] {
 			s.conflictedSet[col] = true
 			s.conflictedList = append(s.conflictedList, col)
 		}
@@ -124,12 +158,25 @@ func newSolver(n int) *Solver {
 }
 
 func (s *Solver) computeConflicts(col, row int) int {
-	return (s.rowCounts[row] - 1) +
+	c := (s.rowCounts[row] - 1) +
 		(s.diag1Counts[row-col+s.offset] - 1) +
 		(s.diag2Counts[row+col] - 1)
+	if s.isForbidden(col, row) {
+		c++
+	}
+	return c
 }
 
+func (s *Solver) isForbidden(col, row int) bool {
+	return s.forbidden != nil && s.forbidden[col*s.n+row]
+}
+
+// markConflicted updates the conflicts of col. Fixed columns are never
+// listed: their conflicts also show on the free queens they attack.
 func (s *Solver) markConflicted(col int) {
+	if s.fixed[col] {
+		return
+	}
 	c := s.computeConflicts(col, s.state[col])
 	s.conflicts[col] = c
 	_, present := s.conflictedSet[col]
@@ -182,29 +229,32 @@ func (s *Solver) move(col, newRow int) {
 		}
 	}
 
-	affected := make(map[int]bool)
+	// A slice, not a map: the order of markConflicted decides the order
+	// of conflictedList and so which column a seeded run picks next
+	var affected []int
 	for _, c := range scanPool {
 		if c == col {
-			affected[c] = true
+			affected = append(affected, c)
 			continue
 		}
 		r := s.state[c]
 		if r == oldRow || r == newRow ||
 			(r-c+s.offset) == oldD1 || (r-c+s.offset) == newD1 ||
 			(r+c) == oldD2 || (r+c) == newD2 {
-			affected[c] = true
+			affected = append(affected, c)
 		}
 	}
 
-	for c := range affected {
+	for _, c := range affected {
 		s.markConflicted(c)
 	}
 }
 
 func (s *Solver) restart() {
-	// Random shuffle
-	s.rng.Shuffle(s.n, func(i, j int) {
-		s.state[i], s.state[j] = s.state[j], s.state[i]
+	// Random shuffle of the free columns
+	s.rng.Shuffle(len(s.free), func(i, j int) {
+		a, b := s.free[i], s.free[j]
+		s.state[a], s.state[b] = s.state[b], s.state[a]
 	})
 
 	// Rebuild counts
@@ -231,7 +281,7 @@ func (s *Solver) restart() {
 	for col, row := range s.state {
 		c := s.computeConflicts(col, row)
 		s.conflicts[col] = c
-		if c > 0 {
+		if c > 0 && !s.fixed[col] {
 			s.conflictedSet[col] = true
 			s.conflictedList = append(s.conflictedList, col)
 		}
@@ -246,6 +296,7 @@ func (s *Solver) solve(maxSteps int) []int {
 	stepsSinceRestart := 0
 
 	for step := 0; step < maxSteps; step++ {
+		s.steps = step
 		if len(s.conflictedList) == 0 {
 			return s.state
 		}
@@ -287,12 +338,16 @@ func (s *Solver) solve(maxSteps int) []int {
 			for r := range candidateSet {
 				candidateRows = append(candidateRows, r)
 			}
+			sort.Ints(candidateRows) // map order would break seeded runs
 		}
 
 		// Find best row
 		minConf := int(^uint(0) >> 1) // Max int
 		bestRows := make([]int, 0, 10)
 		for _, r := range candidateRows {
+			if s.isForbidden(col, r) {
+				continue
+			}
 			c := s.computeConflicts(col, r)
 			if c < minConf {
 				minConf = c
@@ -303,25 +358,33 @@ func (s *Solver) solve(maxSteps int) []int {
 			}
 		}
 
+		if len(bestRows) == 0 {
+			// Every sampled row is forbidden
+			stepsSinceRestart++
+			continue
+		}
 		newRow := bestRows[s.rng.Intn(len(bestRows))]
 		s.move(col, newRow)
 		stepsSinceRestart++
 	}
 
+	s.steps = maxSteps
 	return nil
 }
 
-func solveNQueens(n int, maxSteps int) []int {
+// solveNQueens returns a solution, or nil, and the Min-Conflicts steps it
+// took (0 when none were needed). Runs with the same n and seed agree.
+func solveNQueens(n int, maxSteps int, seed int64) ([]int, int) {
 	if n == 2 || n == 3 {
-		return nil
+		return nil, 0
 	}
 	if n == 1 {
-		return []int{0}
+		return []int{0}, 0
 	}
 
 	// Use constructive solution for large n
 	if n >= 500 {
-		return constructiveSolution(n)
+		return constructiveSolution(n), 0
 	}
 
 	// Min-Conflicts for smaller n
@@ -332,18 +395,27 @@ func solveNQueens(n int, maxSteps int) []int {
 		}
 	}
 
-	solver := newSolver(n)
-	return solver.solve(maxSteps)
+	solver := newSolver(n, seed, nil)
+	return solver.solve(maxSteps), solver.steps
 }
 
-func main() {
-	timeOnly := os.Getenv("FMI_TIME_ONLY") == "1"
+// isFlagSet reports whether the flag name was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
 
-	// Read N from command line or stdin
+// readN parses N from arg, or reads it from stdin when arg is empty.
+func readN(arg string) int {
 	var n int
-	if len(os.Args) > 1 {
+	if arg != "" {
 		var err error
-		n, err = strconv.Atoi(os.Args[1])
+		n, err = strconv.Atoi(arg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Invalid input: %v\n", err)
 			os.Exit(1)
@@ -351,10 +423,36 @@ func main() {
 	} else {
 		fmt.Scan(&n)
 	}
+	return n
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "count":
+			countMain(os.Args[2:])
+			return
+		case "all":
+			allMain(os.Args[2:])
+			return
+		case "complete":
+			completeMain(os.Args[2:])
+			return
+		}
+	}
+	timeOnly := os.Getenv("FMI_TIME_ONLY") == "1"
+	seed := flag.Int64("seed", 0, "seed of the Min-Conflicts run (default: from the clock, printed to repeat the run)")
+	flag.Parse()
+	if !isFlagSet(flag.CommandLine, "seed") {
+		*seed = time.Now().UnixNano()
+	}
+
+	// Read N from command line or stdin
+	n := readN(flag.Arg(0))
 
 	// Solve
 	start := time.Now()
-	result := solveNQueens(n, 0)
+	result, steps := solveNQueens(n, 0, *seed)
 	elapsed := time.Since(start)
 
 	elapsedMs := float64(elapsed.Nanoseconds()) / 1e6
@@ -364,6 +462,7 @@ func main() {
 		fmt.Printf("# TIMES_MS: alg=%.3f\n", elapsedMs)
 	} else {
 		fmt.Printf("# TIMES_MS: alg=%.3f\n", elapsedMs)
+		fmt.Printf("# SEED: %d steps=%d\n", *seed, steps)
 		if result == nil {
 			fmt.Println(-1)
 		} else {
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSeededRuns(t *testing.T) {
+	for _, n := range []int{8, 60, 300} {
+		first, steps := solveNQueens(n, 0, 42)
+		again, stepsAgain := solveNQueens(n, 0, 42)
+		if fmt.Sprint(first) != fmt.Sprint(again) || steps != stepsAgain {
+			t.Errorf("n=%d, seed 42: %v in %d steps, then %v in %d", n, first, steps, again, stepsAgain)
+		}
+	}
+
+	// Boards above 2000 sample the candidate rows.
+	runs := make([]string, 2)
+	for k := range runs {
+		s := newSolver(2100, 7, nil)
+		sol := s.solve(20 * 2100)
+		runs[k] = fmt.Sprint(s.steps, sol)
+	}
+	if runs[0] != runs[1] {
+		t.Error("n=2100, seed 7: two runs differ")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"strconv"
+	"time"
+)
+
+// eachSolution calls visit with every solution on an n×n board, as the row
+// of the queen in each column, in lexicographic order until visit returns
+// false. The slice is reused between calls. Only the current solution is
+// held, so the enumeration runs in O(n) memory.
+func eachSolution(n int, visit func(sol []int) bool) {
+	all := uint64(1)<<n - 1
+	sol := make([]int, n)
+	var place func(col int, rows, up, down uint64) bool
+	place = func(col int, rows, up, down uint64) bool {
+		if col == n {
+			return visit(sol)
+		}
+		free := all &^ (rows | up | down)
+		for free != 0 {
+			bit := free & -free
+			free ^= bit
+			sol[col] = bits.TrailingZeros64(bit)
+			if !place(col+1, rows|bit, (up|bit)<<1, (down|bit)>>1) {
+				return false
+			}
+		}
+		return true
+	}
+	place(0, 0, 0, 0)
+}
+
+// appendSolution appends sol as fmt.Println prints it: "[1 3 0 2]\n".
+func appendSolution(buf []byte, sol []int) []byte {
+	buf = append(buf, '[')
+	for k, row := range sol {
+		if k > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = strconv.AppendInt(buf, int64(row), 10)
+	}
+	return append(buf, ']', '\n')
+}
+
+// writeSolutions writes the solutions on an n×n board in the order of
+// eachSolution, skipping the first offset and stopping after limit (all of
+// them if limit is 0). It returns how many it wrote.
+func writeSolutions(w io.Writer, n int, offset, limit uint64) (uint64, error) {
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	var seen, written uint64
+	var err error
+	eachSolution(n, func(sol []int) bool {
+		seen++
+		if seen <= offset {
+			return true
+		}
+		buf = appendSolution(buf[:0], sol)
+		if _, err = bw.Write(buf); err != nil {
+			return false
+		}
+		written++
+		return limit == 0 || written < limit
+	})
+	if err != nil {
+		return written, err
+	}
+	return written, bw.Flush()
+}
+
+// allMain implements "n-queens all N": it streams the solutions one per line
+// in the format of a single answer and prints the time line after them.
+func allMain(args []string) {
+	fs := flag.NewFlagSet("all", flag.ExitOnError)
+	offset := fs.Uint64("offset", 0, "skip this many solutions first")
+	limit := fs.Uint64("limit", 0, "print at most this many solutions (0 for all)")
+	fs.Parse(args)
+	n := readN(fs.Arg(0))
+	if n < 1 || n > maxCountN {
+		fmt.Fprintf(os.Stderr, "all: N must be between 1 and %d, got %d\n", maxCountN, n)
+		os.Exit(1)
+	}
+
+	out := io.Writer(os.Stdout)
+	if os.Getenv("FMI_TIME_ONLY") == "1" {
+		out = io.Discard
+	}
+	start := time.Now()
+	if _, err := writeSolutions(out, n, *offset, *limit); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("# TIMES_MS: alg=%.3f\n", float64(time.Since(start).Nanoseconds())/1e6)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEachSolution(t *testing.T) {
+	for n := 1; n <= 10; n++ {
+		var count uint64
+		prev := ""
+		eachSolution(n, func(sol []int) bool {
+			count++
+			line := string(appendSolution(nil, sol))
+			if want := fmt.Sprintln(sol); line != want {
+				t.Fatalf("n=%d: appendSolution wrote %q, fmt.Println %q", n, line, want)
+			}
+			if line <= prev {
+				t.Errorf("n=%d: %s after %s is out of order", n, line, prev)
+			}
+			prev = line
+			for c := range sol {
+				for d := c + 1; d < n; d++ {
+					if sol[c] == sol[d] || sol[c]-sol[d] == c-d || sol[c]-sol[d] == d-c {
+						t.Fatalf("n=%d: queens of %v attack each other", n, sol)
+					}
+				}
+			}
+			return true
+		})
+		if count != knownTotals[n-1] {
+			t.Errorf("n=%d: %d solutions, want %d", n, count, knownTotals[n-1])
+		}
+	}
+}
+
+func TestWriteSolutionsPages(t *testing.T) {
+	var all bytes.Buffer
+	if _, err := writeSolutions(&all, 8, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	var pages bytes.Buffer
+	for offset := uint64(0); ; offset += 10 {
+		written, err := writeSolutions(&pages, 8, offset, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written < 10 {
+			break
+		}
+	}
+	if pages.String() != all.String() {
+		t.Error("pages of 10 do not add up to the full listing")
+	}
+	if lines := strings.Count(all.String(), "\n"); lines != 92 {
+		t.Errorf("%d lines, want 92", lines)
+	}
+}